@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/loadgen"
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+)
+
+// runLoadTest implements the loadtest subcommand: it runs a single load test
+// against the configured gRPC server and prints the report as JSON.
+func runLoadTest(args []string, client pb.ServerClient, streamingClient pb.StreamingServerClient) {
+	config := loadgen.DefaultConfig()
+
+	flags := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	flags.StringVar(&config.RPC, "rpc", config.RPC, "RPC to drive: unary, server_stream, client_stream or bidi_stream")
+	flags.Float64Var(&config.Rate, "rate", config.Rate, "calls started per second, 0 runs workers back to back")
+	flags.IntVar(&config.Concurrency, "concurrency", config.Concurrency, "number of concurrent workers")
+	duration := flags.Duration("duration", time.Duration(config.Duration), "how long to run")
+	timeout := flags.Duration("timeout", time.Duration(config.Timeout), "per call timeout, 0 disables it")
+	flags.IntVar(&config.Messages, "messages", config.Messages, "messages per client or bidirectional stream")
+	flags.StringVar(&config.Message, "message", config.Message, "message to send")
+	flags.IntVar(&config.PayloadSize, "payload-size", config.PayloadSize, "bytes sent in the payload of every request message")
+	flags.StringVar(&config.PayloadContent, "payload-content", config.PayloadContent, "payload content: zeros, random, pattern or text")
+	flags.Func("metadata", "key=value metadata sent with every call, repeatable", func(value string) error {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value, got %q", value)
+		}
+		if config.Metadata == nil {
+			config.Metadata = make(map[string]string)
+		}
+		config.Metadata[key] = val
+		return nil
+	})
+	flags.Parse(args)
+
+	config.Duration = loadgen.Duration(*duration)
+	config.Timeout = loadgen.Duration(*timeout)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := loadgen.NewRunner(client, streamingClient).Run(ctx, config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("load test failed")
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+}
@@ -1,14 +1,29 @@
 package main
 
 import (
-	"crypto/tls"
-	"sync"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/lb"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/metrics"
 	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/server"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/serviceconfig"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/session"
 	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/settings"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/tlsconfig"
+	"github.com/zufardhiyaulhaq/echo-grpc/pkg/compression"
+	"github.com/zufardhiyaulhaq/echo-grpc/pkg/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
 
 	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
@@ -17,17 +32,34 @@ import (
 func main() {
 	settings, err := settings.NewSettings()
 	if err != nil {
-		log.Fatal().AnErr("failed to get settings", err)
+		log.Fatal().Err(err).Msg("failed to get settings")
 	}
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName:    settings.TracingServiceName,
+		ServiceVersion: version,
+		Exporter:       settings.TracingExporter,
+		OTLPEndpoint:   settings.TracingOTLPEndpoint,
+		OTLPInsecure:   settings.TracingOTLPInsecure,
+		FilePath:       settings.TracingFilePath,
+		SampleRatio:    settings.TracingSampleRatio,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to set up tracing")
+	}
+	defer shutdownTracing(context.Background())
+
 	log.Info().Msg("creating grpc connection")
 
-	var opts []grpc.DialOption
+	opts := []grpc.DialOption{
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
 
 	if settings.GRPCServerTLS {
 		log.Info().Msg("setting gRPC to call with TLS")
-		config := &tls.Config{
-			InsecureSkipVerify: true,
+		config, err := tlsconfig.New(settings)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid TLS settings")
 		}
 		creds := credentials.NewTLS(config)
 		opts = append(opts, grpc.WithTransportCredentials(creds))
@@ -44,26 +76,106 @@ func main() {
 		opts = append(opts, grpc.WithKeepaliveParams(keepaliveParams))
 	}
 
-	conn, err := grpc.Dial(settings.GRPCServerHost+":"+settings.GRPCServerPort, opts...)
+	if settings.GRPCCompressor != "" && settings.GRPCCompressor != compression.Identity {
+		if encoding.GetCompressor(settings.GRPCCompressor) == nil {
+			log.Fatal().Str("compressor", settings.GRPCCompressor).Msg("unknown gRPC compressor")
+		}
+		log.Info().Str("compressor", settings.GRPCCompressor).Msg("setting gRPC to compress requests")
+		opts = append(opts, grpc.WithDefaultCallOptions(grpc.UseCompressor(settings.GRPCCompressor)))
+	}
+
+	serviceConfig, err := serviceconfig.Load(settings.GRPCServiceConfigFile, settings.GRPCLBPolicy)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid service config settings")
+	}
+	log.Info().RawJSON("service_config", []byte(serviceConfig)).Msg("setting gRPC service config")
+	opts = append(opts, grpc.WithDefaultServiceConfig(serviceConfig))
+
+	if settings.LBHistorySize < 0 {
+		log.Fatal().Int("lb_history_size", settings.LBHistorySize).Msg("LB history size must not be negative")
+	}
+	tracker := lb.NewTracker(settings.LBHistorySize)
+	opts = append(opts,
+		grpc.WithChainUnaryInterceptor(
+			metrics.UnaryClientInterceptor(),
+			tracker.UnaryClientInterceptor(),
+		),
+		grpc.WithChainStreamInterceptor(
+			metrics.StreamClientInterceptor(),
+			tracing.StreamClientInterceptor(),
+		),
+	)
+
+	target := settings.GRPCServerTarget
+	if target == "" {
+		target = settings.GRPCServerHost + ":" + settings.GRPCServerPort
+	}
+
+	log.Info().Str("target", target).Msg("dialing gRPC server")
+	conn, err := grpc.Dial(target, opts...)
 	if err != nil {
-		log.Fatal().AnErr("failed to start connection", err)
+		log.Fatal().Err(err).Msg("failed to start connection")
 	}
 	defer conn.Close()
 
 	client := pb.NewServerClient(conn)
 	streamingClient := pb.NewStreamingServerClient(conn)
+	adminClient := pb.NewAdminClient(conn)
+	healthClient := healthpb.NewHealthClient(conn)
 
-	wg := new(sync.WaitGroup)
-	wg.Add(2)
+	if len(os.Args) > 1 && os.Args[1] == "loadtest" {
+		runLoadTest(os.Args[2:], client, streamingClient)
+		return
+	}
+
+	overflowPolicy, err := session.ParseOverflowPolicy(settings.WSOverflowPolicy)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid WebSocket overflow policy")
+	}
+
+	sessionConfig := session.Config{
+		QueueSize:    settings.WSQueueSize,
+		Overflow:     overflowPolicy,
+		PingInterval: settings.WSPingInterval,
+		PongWait:     settings.WSPongWait,
+		WriteWait:    settings.WSWriteWait,
+	}
+	if err := sessionConfig.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid WebSocket settings")
+	}
+	if settings.WSMuxMaxStreams < 1 {
+		log.Fatal().Int("ws_mux_max_streams", settings.WSMuxMaxStreams).Msg("WS_MUX_MAX_STREAMS must be at least 1")
+	}
 
 	log.Info().Msg("starting server")
-	server := server.NewServer(settings, client, streamingClient)
+	server := server.NewServer(settings, client, streamingClient, adminClient, healthClient, tracker, sessionConfig)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	go func() {
 		log.Info().Msg("starting HTTP server")
-		server.ServeHTTP()
-		wg.Done()
+		if err := server.ServeHTTP(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msg("failed to serve HTTP")
+		}
 	}()
 
-	wg.Wait()
+	<-ctx.Done()
+	stop()
+
+	log.Info().
+		Dur("drain_period", settings.ShutdownDrainPeriod).
+		Msg("shutting down: failing readiness")
+	server.Drain()
+	time.Sleep(settings.ShutdownDrainPeriod)
+
+	log.Info().Msg("shutting down: closing HTTP and WebSocket connections")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), settings.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Warn().Err(err).Msg("shutting down: timed out waiting for requests, forcing stop")
+		return
+	}
+	log.Info().Msg("shutting down: HTTP server stopped")
 }
@@ -0,0 +1,169 @@
+// Package lb configures client side load balancing and keeps track of which
+// backend answered the latest calls.
+package lb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/balancer/weightedroundrobin"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	PolicyPickFirst          = "pick_first"
+	PolicyRoundRobin         = "round_robin"
+	PolicyWeightedRoundRobin = "weighted_round_robin"
+)
+
+// HeaderHostname is the response header the echo server puts its hostname in.
+const HeaderHostname = "x-echo-hostname"
+
+// LoadBalancingConfig returns the loadBalancingConfig service config field
+// selecting policy. weighted_round_robin weighs backends by the ORCA load
+// reports they attach to every response and falls back to plain round robin
+// without them.
+func LoadBalancingConfig(policy string) (json.RawMessage, error) {
+	switch policy {
+	case PolicyPickFirst, PolicyRoundRobin, PolicyWeightedRoundRobin:
+	default:
+		return nil, fmt.Errorf("unknown load balancing policy %q", policy)
+	}
+
+	return json.RawMessage(fmt.Sprintf(`[{%q:{}}]`, policy)), nil
+}
+
+// Call is a completed unary call and the backend that answered it.
+type Call struct {
+	Time     time.Time `json:"time"`
+	Method   string    `json:"method"`
+	Backend  string    `json:"backend"`
+	Hostname string    `json:"hostname,omitempty"`
+	Code     string    `json:"code"`
+}
+
+// Share is the part of the recent calls answered by a backend.
+type Share struct {
+	Backend  string  `json:"backend"`
+	Hostname string  `json:"hostname,omitempty"`
+	Calls    int     `json:"calls"`
+	Percent  float64 `json:"percent"`
+}
+
+type Report struct {
+	Window       int     `json:"window"`
+	Calls        int     `json:"calls"`
+	Distribution []Share `json:"distribution"`
+	Recent       []Call  `json:"recent"`
+}
+
+// Tracker remembers the backend of the last calls in a ring buffer.
+type Tracker struct {
+	mu    sync.Mutex
+	calls []Call
+	next  int
+	full  bool
+}
+
+func NewTracker(size int) *Tracker {
+	return &Tracker{
+		calls: make([]Call, size),
+	}
+}
+
+func (t *Tracker) Record(call Call) {
+	if len(t.calls) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.calls[t.next] = call
+	t.next = (t.next + 1) % len(t.calls)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// Recent returns the remembered calls, oldest first.
+func (t *Tracker) Recent() []Call {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.full {
+		return append([]Call(nil), t.calls[:t.next]...)
+	}
+	return append(append([]Call(nil), t.calls[t.next:]...), t.calls[:t.next]...)
+}
+
+// Report summarizes the remembered calls by backend, busiest first.
+func (t *Tracker) Report() Report {
+	recent := t.Recent()
+
+	shares := make(map[string]*Share)
+	for _, call := range recent {
+		share, ok := shares[call.Backend]
+		if !ok {
+			share = &Share{Backend: call.Backend}
+			shares[call.Backend] = share
+		}
+		share.Calls++
+		if call.Hostname != "" {
+			share.Hostname = call.Hostname
+		}
+	}
+
+	distribution := make([]Share, 0, len(shares))
+	for _, share := range shares {
+		share.Percent = float64(share.Calls) * 100 / float64(len(recent))
+		distribution = append(distribution, *share)
+	}
+	sort.Slice(distribution, func(i, j int) bool {
+		if distribution[i].Calls != distribution[j].Calls {
+			return distribution[i].Calls > distribution[j].Calls
+		}
+		return distribution[i].Backend < distribution[j].Backend
+	})
+
+	return Report{
+		Window:       len(t.calls),
+		Calls:        len(recent),
+		Distribution: distribution,
+		Recent:       recent,
+	}
+}
+
+// UnaryClientInterceptor records the backend of every unary call.
+func (t *Tracker) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var (
+			p      peer.Peer
+			header metadata.MD
+		)
+
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p), grpc.Header(&header))...)
+
+		call := Call{
+			Time:   time.Now(),
+			Method: method,
+			Code:   status.Code(err).String(),
+		}
+		if p.Addr != nil {
+			call.Backend = p.Addr.String()
+		}
+		if values := header.Get(HeaderHostname); len(values) > 0 {
+			call.Hostname = values[0]
+		}
+		t.Record(call)
+
+		return err
+	}
+}
@@ -0,0 +1,97 @@
+package loadgen
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// histogramGrowth is the ratio between the bounds of two buckets, so
+	// percentiles are reported within 1% of the recorded latency.
+	histogramGrowth = 1.01
+	// histogramBuckets covers latencies up to about 2.5 hours, longer ones
+	// share the last bucket.
+	histogramBuckets = 3000
+)
+
+var logGrowth = math.Log(histogramGrowth)
+
+// histogram records latencies in a fixed number of log-scaled buckets, so a
+// run takes the same memory however many calls it makes. Min, max and mean
+// are exact.
+type histogram struct {
+	counts [histogramBuckets]int
+	count  int
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+func (h *histogram) record(latency time.Duration) {
+	if h.count == 0 || latency < h.min {
+		h.min = latency
+	}
+	if latency > h.max {
+		h.max = latency
+	}
+	h.count++
+	h.sum += latency
+	h.counts[bucket(latency)]++
+}
+
+// bucket returns the index of the bucket whose upper bound is the first one
+// at or above latency.
+func bucket(latency time.Duration) int {
+	if latency <= 1 {
+		return 0
+	}
+
+	i := int(math.Ceil(math.Log(float64(latency)) / logGrowth))
+	if i >= histogramBuckets {
+		return histogramBuckets - 1
+	}
+	return i
+}
+
+// percentile uses the nearest-rank method, reporting the upper bound of the
+// bucket holding the rank, kept within the recorded min and max.
+func (h *histogram) percentile(p float64) time.Duration {
+	rank := int(math.Ceil(float64(h.count) * p / 100))
+	if rank < 1 {
+		rank = 1
+	}
+
+	seen := 0
+	for i, count := range h.counts {
+		seen += count
+		if seen < rank {
+			continue
+		}
+
+		latency := time.Duration(math.Pow(histogramGrowth, float64(i)))
+		switch {
+		case latency < h.min:
+			return h.min
+		case latency > h.max:
+			return h.max
+		default:
+			return latency
+		}
+	}
+	return h.max
+}
+
+func (h *histogram) summarize() Latency {
+	if h.count == 0 {
+		return Latency{}
+	}
+
+	return Latency{
+		Min:  Duration(h.min),
+		Mean: Duration(h.sum / time.Duration(h.count)),
+		P50:  Duration(h.percentile(50)),
+		P90:  Duration(h.percentile(90)),
+		P99:  Duration(h.percentile(99)),
+		Max:  Duration(h.max),
+	}
+}
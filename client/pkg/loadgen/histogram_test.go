@@ -0,0 +1,85 @@
+package loadgen
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistogramEmpty(t *testing.T) {
+	var h histogram
+	if got := h.summarize(); got != (Latency{}) {
+		t.Errorf("summarize() = %+v, want zero", got)
+	}
+}
+
+func TestHistogramSingle(t *testing.T) {
+	var h histogram
+	h.record(3 * time.Millisecond)
+
+	want := Duration(3 * time.Millisecond)
+	got := h.summarize()
+	for name, d := range map[string]Duration{"min": got.Min, "mean": got.Mean, "p50": got.P50, "p90": got.P90, "p99": got.P99, "max": got.Max} {
+		if d != want {
+			t.Errorf("%s = %s, want %s", name, time.Duration(d), time.Duration(want))
+		}
+	}
+}
+
+func TestHistogramPercentiles(t *testing.T) {
+	var h histogram
+	// 1ms to 1000ms, so the nearest-rank percentile p is p*10ms.
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+
+	got := h.summarize()
+	if got.Min != Duration(time.Millisecond) || got.Max != Duration(time.Second) {
+		t.Errorf("min, max = %s, %s, want exact 1ms, 1s", time.Duration(got.Min), time.Duration(got.Max))
+	}
+	if got.Mean != Duration(500500*time.Microsecond) {
+		t.Errorf("mean = %s, want exact 500.5ms", time.Duration(got.Mean))
+	}
+
+	for _, tt := range []struct {
+		name string
+		got  Duration
+		want time.Duration
+	}{
+		{"p50", got.P50, 500 * time.Millisecond},
+		{"p90", got.P90, 900 * time.Millisecond},
+		{"p99", got.P99, 990 * time.Millisecond},
+	} {
+		// Reported as the upper bound of the bucket, at most 1% above.
+		low, high := tt.want, time.Duration(float64(tt.want)*histogramGrowth)
+		if d := time.Duration(tt.got); d < low || d > high {
+			t.Errorf("%s = %s, want between %s and %s", tt.name, d, low, high)
+		}
+	}
+}
+
+func TestHistogramBucket(t *testing.T) {
+	tests := []struct {
+		latency time.Duration
+		want    int
+	}{
+		{-time.Second, 0},
+		{0, 0},
+		{1, 0},
+		{24 * time.Hour, histogramBuckets - 1},
+	}
+	for _, tt := range tests {
+		if got := bucket(tt.latency); got != tt.want {
+			t.Errorf("bucket(%s) = %d, want %d", tt.latency, got, tt.want)
+		}
+	}
+
+	// Buckets grow with the latency.
+	last := 0
+	for d := time.Duration(2); d < time.Hour; d = d*3/2 + 1 {
+		i := bucket(d)
+		if i < last || i >= histogramBuckets {
+			t.Fatalf("bucket(%s) = %d, after %d", d, i, last)
+		}
+		last = i
+	}
+}
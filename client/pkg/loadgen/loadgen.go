@@ -0,0 +1,319 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zufardhiyaulhaq/echo-grpc/pkg/payload"
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	RPCUnary        = "unary"
+	RPCServerStream = "server_stream"
+	RPCClientStream = "client_stream"
+	RPCBidiStream   = "bidi_stream"
+)
+
+const (
+	// MaxRate bounds Config.Rate, one call per microsecond.
+	MaxRate = 1e6
+	// MaxConcurrency bounds Config.Concurrency, one goroutine per worker.
+	MaxConcurrency = 10000
+	// MaxDuration bounds Config.Duration.
+	MaxDuration = time.Hour
+)
+
+type Config struct {
+	RPC string `json:"rpc"`
+	// Rate is the number of calls started per second across all workers, 0
+	// runs every worker back to back. With a rate, latency is measured from
+	// the time a call was scheduled to start, so calls that wait for a busy
+	// worker report that wait instead of hiding it.
+	Rate        float64  `json:"rate"`
+	Concurrency int      `json:"concurrency"`
+	Duration    Duration `json:"duration"`
+	// Timeout bounds every single call, 0 disables it.
+	Timeout Duration `json:"timeout"`
+	// Messages is the number of messages sent per client or bidirectional
+	// stream.
+	Messages int    `json:"messages"`
+	Message  string `json:"message"`
+	// PayloadSize bytes of PayloadContent are sent in the payload field of
+	// every request message.
+	PayloadSize    int    `json:"payload_size"`
+	PayloadContent string `json:"payload_content"`
+	// Metadata is sent with every call, e.g. x-echo-payload-size to ask for
+	// large responses.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		RPC:            RPCUnary,
+		Concurrency:    1,
+		Duration:       Duration(10 * time.Second),
+		Timeout:        Duration(30 * time.Second),
+		Messages:       10,
+		Message:        "loadgen",
+		PayloadContent: payload.ContentZeros,
+	}
+}
+
+func (c Config) Validate() error {
+	switch c.RPC {
+	case RPCUnary, RPCServerStream, RPCClientStream, RPCBidiStream:
+	default:
+		return fmt.Errorf("unknown rpc %q", c.RPC)
+	}
+
+	if c.Concurrency < 1 || c.Concurrency > MaxConcurrency {
+		return fmt.Errorf("concurrency must be between 1 and %d", MaxConcurrency)
+	}
+	if c.Duration <= 0 || time.Duration(c.Duration) > MaxDuration {
+		return fmt.Errorf("duration must be positive and at most %s", MaxDuration)
+	}
+	// Written so a NaN rate fails too.
+	if !(c.Rate >= 0 && c.Rate <= MaxRate) {
+		return fmt.Errorf("rate must be between 0 and %g", float64(MaxRate))
+	}
+	if c.Messages < 1 && (c.RPC == RPCClientStream || c.RPC == RPCBidiStream) {
+		return fmt.Errorf("messages must be at least 1")
+	}
+	if err := c.payloadSpec().Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c Config) payloadSpec() payload.Spec {
+	return payload.Spec{
+		Distribution: payload.DistributionFixed,
+		Size:         c.PayloadSize,
+		Content:      c.PayloadContent,
+		Pattern:      "echo",
+	}
+}
+
+type Report struct {
+	Config     Config         `json:"config"`
+	Elapsed    Duration       `json:"elapsed"`
+	Requests   int            `json:"requests"`
+	Errors     int            `json:"errors"`
+	Throughput float64        `json:"throughput"`
+	Codes      map[string]int `json:"codes"`
+	Latency    Latency        `json:"latency"`
+}
+
+type Latency struct {
+	Min  Duration `json:"min"`
+	Mean Duration `json:"mean"`
+	P50  Duration `json:"p50"`
+	P90  Duration `json:"p90"`
+	P99  Duration `json:"p99"`
+	Max  Duration `json:"max"`
+}
+
+type Runner struct {
+	client          pb.ServerClient
+	streamingClient pb.StreamingServerClient
+}
+
+func NewRunner(client pb.ServerClient, streamingClient pb.StreamingServerClient) *Runner {
+	return &Runner{
+		client:          client,
+		streamingClient: streamingClient,
+	}
+}
+
+// Run starts calls of the configured RPC for the configured duration, waits
+// for the calls in flight to finish and reports on them. Cancelling ctx stops
+// the run early and discards the interrupted calls.
+func (r *Runner) Run(ctx context.Context, config Config) (Report, error) {
+	if err := config.Validate(); err != nil {
+		return Report{}, err
+	}
+
+	data := config.payloadSpec().Generate()
+	if len(config.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(config.Metadata))
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(config.Duration))
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		latencies histogram
+		codes     = make(map[string]int)
+		scheduled atomic.Int64
+	)
+
+	start := time.Now()
+	end := start.Add(time.Duration(config.Duration))
+
+	// next waits for the start of the next call, returning the time it was
+	// scheduled at, or false once the run is over.
+	next := func() (time.Time, bool) {
+		if config.Rate == 0 {
+			return time.Now(), runCtx.Err() == nil
+		}
+
+		call := scheduled.Add(1) - 1
+		at := start.Add(time.Duration(float64(call) * float64(time.Second) / config.Rate))
+		if !at.Before(end) {
+			return at, false
+		}
+
+		timer := time.NewTimer(time.Until(at))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return at, true
+		case <-runCtx.Done():
+			return at, false
+		}
+	}
+
+	wg := new(sync.WaitGroup)
+	for worker := 0; worker < config.Concurrency; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			for call := 0; ; call++ {
+				at, ok := next()
+				if !ok {
+					return
+				}
+
+				err := r.call(ctx, config, data, fmt.Sprintf("loadgen-%d-%d", worker, call))
+				latency := time.Since(at)
+				if ctx.Err() != nil {
+					return
+				}
+
+				mu.Lock()
+				latencies.record(latency)
+				codes[status.Code(err).String()]++
+				mu.Unlock()
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	report := Report{
+		Config:     config,
+		Elapsed:    Duration(elapsed),
+		Requests:   latencies.count,
+		Errors:     latencies.count - codes["OK"],
+		Throughput: float64(latencies.count) / elapsed.Seconds(),
+		Codes:      codes,
+		Latency:    latencies.summarize(),
+	}
+
+	return report, nil
+}
+
+func (r *Runner) call(ctx context.Context, config Config, data []byte, streamID string) error {
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(config.Timeout))
+		defer cancel()
+	}
+
+	switch config.RPC {
+	case RPCUnary:
+		_, err := r.client.GetReply(ctx, &pb.Message{Message: config.Message, Payload: data})
+		return err
+	case RPCServerStream:
+		return r.serverStream(ctx, config, data, streamID)
+	case RPCClientStream:
+		return r.clientStream(ctx, config, data, streamID)
+	default:
+		return r.bidiStream(ctx, config, data, streamID)
+	}
+}
+
+func (r *Runner) serverStream(ctx context.Context, config Config, data []byte, streamID string) error {
+	stream, err := r.streamingClient.ServerStream(ctx, &pb.StreamMessage{
+		StreamId:  streamID,
+		Timestamp: time.Now().UnixNano(),
+		Message:   config.Message,
+		Payload:   data,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (r *Runner) clientStream(ctx context.Context, config Config, data []byte, streamID string) error {
+	stream, err := r.streamingClient.ClientStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i <= config.Messages; i++ {
+		if err := stream.Send(newStreamMessage(config, data, streamID, i)); err != nil {
+			break
+		}
+	}
+
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+// bidiStream sends every message and waits for its echo before sending the
+// next one.
+func (r *Runner) bidiStream(ctx context.Context, config Config, data []byte, streamID string) error {
+	stream, err := r.streamingClient.BidirectionalStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i <= config.Messages; i++ {
+		if err := stream.Send(newStreamMessage(config, data, streamID, i)); err != nil {
+			_, err = stream.Recv()
+			return err
+		}
+		if _, err := stream.Recv(); err != nil {
+			return err
+		}
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	if _, err := stream.Recv(); err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+func newStreamMessage(config Config, data []byte, streamID string, sequence int) *pb.StreamMessage {
+	return &pb.StreamMessage{
+		StreamId:       streamID,
+		SequenceNumber: int64(sequence),
+		Timestamp:      time.Now().UnixNano(),
+		Message:        config.Message,
+		Payload:        data,
+	}
+}
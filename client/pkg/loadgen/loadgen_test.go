@@ -0,0 +1,56 @@
+package loadgen
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{name: "defaults", modify: func(*Config) {}},
+		{name: "max rate", modify: func(c *Config) { c.Rate = MaxRate }},
+		{name: "unknown rpc", modify: func(c *Config) { c.RPC = "stream" }, wantErr: "unknown rpc"},
+		{name: "max concurrency", modify: func(c *Config) { c.Concurrency = MaxConcurrency }},
+		{name: "max duration", modify: func(c *Config) { c.Duration = Duration(MaxDuration) }},
+		{name: "no concurrency", modify: func(c *Config) { c.Concurrency = 0 }, wantErr: "concurrency"},
+		{name: "concurrency above max", modify: func(c *Config) { c.Concurrency = MaxConcurrency + 1 }, wantErr: "concurrency"},
+		{name: "no duration", modify: func(c *Config) { c.Duration = 0 }, wantErr: "duration"},
+		{name: "negative duration", modify: func(c *Config) { c.Duration = Duration(-time.Second) }, wantErr: "duration"},
+		{name: "duration above max", modify: func(c *Config) { c.Duration = Duration(MaxDuration + 1) }, wantErr: "duration"},
+		{name: "negative rate", modify: func(c *Config) { c.Rate = -1 }, wantErr: "rate"},
+		{name: "rate above max", modify: func(c *Config) { c.Rate = MaxRate * 2 }, wantErr: "rate"},
+		{name: "infinite rate", modify: func(c *Config) { c.Rate = math.Inf(1) }, wantErr: "rate"},
+		{name: "NaN rate", modify: func(c *Config) { c.Rate = math.NaN() }, wantErr: "rate"},
+		{name: "unary without messages", modify: func(c *Config) { c.Messages = 0 }},
+		{
+			name:    "stream without messages",
+			modify:  func(c *Config) { c.RPC = RPCBidiStream; c.Messages = 0 },
+			wantErr: "messages",
+		},
+		{name: "negative payload", modify: func(c *Config) { c.PayloadSize = -1 }, wantErr: "size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tt.modify(&config)
+
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	handled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "echo_grpc_client_handled_total",
+		Help: "Total number of RPCs completed by the client.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"})
+
+	handlingSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "echo_grpc_client_handling_seconds",
+		Help:    "Time taken by the client to complete RPCs.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"})
+
+	activeStreams = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "echo_grpc_client_active_streams",
+		Help: "Number of streaming RPCs currently in progress.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+
+	messagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "echo_grpc_client_msg_received_total",
+		Help: "Total number of stream messages received by the client.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+
+	messagesSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "echo_grpc_client_msg_sent_total",
+		Help: "Total number of stream messages sent by the client.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+
+	streamMessages = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "echo_grpc_client_stream_messages",
+		Help:    "Number of messages exchanged per completed stream.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"grpc_type", "grpc_service", "grpc_method", "direction"})
+
+	webSocketConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "echo_grpc_client_websocket_connections",
+		Help: "Number of open WebSocket connections.",
+	}, []string{"endpoint"})
+
+	webSocketConnectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "echo_grpc_client_websocket_connections_total",
+		Help: "Total number of accepted WebSocket connections.",
+	}, []string{"endpoint"})
+
+	webSocketQueueOverflows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "echo_grpc_client_websocket_queue_overflows_total",
+		Help: "Total number of messages sent to a full WebSocket outbound queue.",
+	}, []string{"endpoint", "policy"})
+)
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// WebSocketOpened records a new connection on endpoint and returns the
+// function to call once it is closed.
+func WebSocketOpened(endpoint string) func() {
+	webSocketConnections.WithLabelValues(endpoint).Inc()
+	webSocketConnectionsTotal.WithLabelValues(endpoint).Inc()
+
+	return func() {
+		webSocketConnections.WithLabelValues(endpoint).Dec()
+	}
+}
+
+// WebSocketQueueOverflow records a message sent to the full outbound queue
+// of a session on endpoint, handled with policy.
+func WebSocketQueueOverflow(endpoint, policy string) {
+	webSocketQueueOverflows.WithLabelValues(endpoint, policy).Inc()
+}
+
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, fullMethod string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, fullMethod, req, reply, cc, opts...)
+
+		service, method := splitMethod(fullMethod)
+		observe("unary", service, method, err, start)
+
+		return err
+	}
+}
+
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, fullMethod string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		grpcType := streamType(desc)
+		service, method := splitMethod(fullMethod)
+
+		cs, err := streamer(ctx, desc, cc, fullMethod, opts...)
+		if err != nil {
+			observe(grpcType, service, method, err, start)
+			return nil, err
+		}
+
+		activeStreams.WithLabelValues(grpcType, service, method).Inc()
+
+		stream := &clientStream{
+			ClientStream:  cs,
+			serverStreams: desc.ServerStreams,
+			received:      messagesReceived.WithLabelValues(grpcType, service, method),
+			sent:          messagesSent.WithLabelValues(grpcType, service, method),
+			done:          make(chan struct{}),
+		}
+		stream.finish = func(err error) {
+			close(stream.done)
+			activeStreams.WithLabelValues(grpcType, service, method).Dec()
+			observe(grpcType, service, method, err, start)
+			streamMessages.WithLabelValues(grpcType, service, method, "received").Observe(float64(stream.receivedCount.Load()))
+			streamMessages.WithLabelValues(grpcType, service, method, "sent").Observe(float64(stream.sentCount.Load()))
+		}
+
+		// A stream cancelled or abandoned before its last RecvMsg is recorded
+		// when its context is done.
+		go func() {
+			select {
+			case <-ctx.Done():
+				stream.once.Do(func() { stream.finish(status.FromContextError(ctx.Err()).Err()) })
+			case <-stream.done:
+			}
+		}()
+
+		return stream, nil
+	}
+}
+
+// clientStream counts messages and records the RPC once the stream reaches a
+// terminal state: a receive error, io.EOF, the single response of a
+// client-streaming RPC, or its context being done.
+type clientStream struct {
+	grpc.ClientStream
+	serverStreams bool
+	received      prometheus.Counter
+	sent          prometheus.Counter
+	receivedCount atomic.Int64
+	sentCount     atomic.Int64
+	finish        func(error)
+	once          sync.Once
+	done          chan struct{}
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.sent.Inc()
+		s.sentCount.Add(1)
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		s.once.Do(func() { s.finish(nil) })
+	case err != nil:
+		s.once.Do(func() { s.finish(err) })
+	default:
+		s.received.Inc()
+		s.receivedCount.Add(1)
+		if !s.serverStreams {
+			s.once.Do(func() { s.finish(nil) })
+		}
+	}
+	return err
+}
+
+func observe(grpcType, service, method string, err error, start time.Time) {
+	code := status.Code(err).String()
+	handled.WithLabelValues(grpcType, service, method, code).Inc()
+	handlingSeconds.WithLabelValues(grpcType, service, method, code).Observe(time.Since(start).Seconds())
+}
+
+func streamType(desc *grpc.StreamDesc) string {
+	switch {
+	case desc.ClientStreams && desc.ServerStreams:
+		return "bidi_stream"
+	case desc.ClientStreams:
+		return "client_stream"
+	default:
+		return "server_stream"
+	}
+}
+
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
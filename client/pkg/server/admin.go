@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type AdminHandler struct {
+	adminClient pb.AdminClient
+}
+
+func NewAdminHandler(adminClient pb.AdminClient) AdminHandler {
+	return AdminHandler{
+		adminClient: adminClient,
+	}
+}
+
+// HandleList returns the serving status of every service registered on the
+// gRPC server.
+func (h AdminHandler) HandleList(w http.ResponseWriter, req *http.Request) {
+	reply, err := h.adminClient.ListServingStatus(req.Context(), &pb.ListServingStatusRequest{})
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	writeProtoJSON(w, reply)
+}
+
+// HandleSet changes the serving status of a service on the gRPC server. The
+// service, status and optional duration are read from the query string, e.g.
+// /admin/health?service=com.gopay.echo.Server&status=NOT_SERVING&duration=30s.
+// An omitted service targets the overall server health.
+func (h AdminHandler) HandleSet(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	// SERVICE_UNKNOWN only describes unregistered services, it can not be
+	// set.
+	servingStatus, ok := pb.HealthCheckResponse_ServingStatus_value[strings.ToUpper(query.Get("status"))]
+	if !ok || pb.HealthCheckResponse_ServingStatus(servingStatus) == pb.HealthCheckResponse_SERVICE_UNKNOWN {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("status must be one of SERVING, NOT_SERVING or UNKNOWN"))
+		return
+	}
+
+	request := &pb.SetServingStatusRequest{
+		Service: query.Get("service"),
+		Status:  pb.HealthCheckResponse_ServingStatus(servingStatus),
+	}
+
+	if value := query.Get("duration"); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid duration: " + err.Error()))
+			return
+		}
+		request.Duration = durationpb.New(duration)
+	}
+
+	reply, err := h.adminClient.SetServingStatus(req.Context(), request)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	writeProtoJSON(w, reply)
+}
+
+func writeProtoJSON(w http.ResponseWriter, message proto.Message) {
+	data, err := protojson.Marshal(message)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/lb"
+)
+
+type BackendsHandler struct {
+	tracker *lb.Tracker
+}
+
+func NewBackendsHandler(tracker *lb.Tracker) BackendsHandler {
+	return BackendsHandler{
+		tracker: tracker,
+	}
+}
+
+// Handle reports which backend answered each of the last unary calls and how
+// the calls are distributed over the backends.
+func (h BackendsHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	data, _ := json.Marshal(h.tracker.Report())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
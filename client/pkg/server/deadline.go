@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// callContext derives the context of the gRPC call made for req. It is
+// cancelled when the HTTP caller goes away and carries the deadline the caller
+// asked for, or defaultTimeout when it did not ask. A zero timeout means no
+// deadline.
+func callContext(req *http.Request, defaultTimeout time.Duration) (context.Context, context.CancelFunc, error) {
+	timeout, ok, err := timeoutFromRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		timeout = defaultTimeout
+	}
+
+	if timeout <= 0 {
+		ctx, cancel := context.WithCancel(req.Context())
+		return ctx, cancel, nil
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	return ctx, cancel, nil
+}
+
+// timeoutFromRequest returns the deadline the HTTP caller asked for with a
+// grpc-timeout header (gRPC wire format, e.g. 250m) or an X-Timeout header (a
+// duration, e.g. 250ms). ok is false when neither is set.
+func timeoutFromRequest(req *http.Request) (timeout time.Duration, ok bool, err error) {
+	if value := req.Header.Get("Grpc-Timeout"); value != "" {
+		timeout, err := parseGRPCTimeout(value)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid grpc-timeout: %w", err)
+		}
+		return timeout, true, nil
+	}
+
+	if value := req.Header.Get("X-Timeout"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return 0, false, fmt.Errorf("invalid X-Timeout: %q", value)
+		}
+		return timeout, true, nil
+	}
+
+	return 0, false, nil
+}
+
+var grpcTimeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// parseGRPCTimeout parses the value of a grpc-timeout header: at most eight
+// digits followed by a unit. Like grpc-go, values too large for a
+// time.Duration (from 2562048H) saturate to the largest one.
+func parseGRPCTimeout(value string) (time.Duration, error) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, fmt.Errorf("%q is not 1 to 8 digits and a unit", value)
+	}
+
+	unit, ok := grpcTimeoutUnits[value[len(value)-1]]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit in %q", value)
+	}
+
+	amount, err := strconv.ParseUint(value[:len(value)-1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not 1 to 8 digits and a unit", value)
+	}
+
+	if amount > uint64(math.MaxInt64/unit) {
+		return math.MaxInt64, nil
+	}
+	return time.Duration(amount) * unit, nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseGRPCTimeout(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "1H", want: time.Hour},
+		{value: "2M", want: 2 * time.Minute},
+		{value: "3S", want: 3 * time.Second},
+		{value: "250m", want: 250 * time.Millisecond},
+		{value: "10u", want: 10 * time.Microsecond},
+		{value: "99999999n", want: 99999999 * time.Nanosecond},
+		{value: "0S", want: 0},
+		{value: "2562047H", want: 2562047 * time.Hour},
+		{value: "2562048H", want: math.MaxInt64},
+		{value: "99999999H", want: math.MaxInt64},
+		{value: "99999999S", want: 99999999 * time.Second},
+		{value: "", wantErr: true},
+		{value: "S", wantErr: true},
+		{value: "1", wantErr: true},
+		{value: "100x", wantErr: true},
+		{value: "100s", wantErr: true},
+		{value: "123456789S", wantErr: true},
+		{value: "-1S", wantErr: true},
+		{value: "+1S", wantErr: true},
+		{value: "1.5S", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseGRPCTimeout(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseGRPCTimeout(%q) = %s, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGRPCTimeout(%q) failed: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseGRPCTimeout(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/settings"
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// metadataHeaderPrefix marks the HTTP request headers the gateway forwards
+// as gRPC metadata, e.g. Grpc-Metadata-X-Echo-Delay: 100ms.
+const metadataHeaderPrefix = "Grpc-Metadata-"
+
+// GatewayReply is the body of every /v1 route. Unary calls fill Response,
+// streaming calls fill Responses, both in protobuf JSON.
+type GatewayReply struct {
+	Status    statusBody        `json:"status"`
+	Headers   metadata.MD       `json:"headers,omitempty"`
+	Trailers  metadata.MD       `json:"trailers,omitempty"`
+	Response  json.RawMessage   `json:"response,omitempty"`
+	Responses []json.RawMessage `json:"responses,omitempty"`
+}
+
+// GatewayStreamRequest is the body of the client and bidirectional stream
+// routes, every message is a StreamMessage in protobuf JSON.
+type GatewayStreamRequest struct {
+	Messages []json.RawMessage `json:"messages"`
+}
+
+type GatewayHandler struct {
+	settings        settings.Settings
+	client          pb.ServerClient
+	streamingClient pb.StreamingServerClient
+}
+
+func NewGatewayHandler(settings settings.Settings, client pb.ServerClient, streamingClient pb.StreamingServerClient) GatewayHandler {
+	return GatewayHandler{
+		settings:        settings,
+		client:          client,
+		streamingClient: streamingClient,
+	}
+}
+
+// HandleEcho calls GetReply with the Message in the request body.
+func (h GatewayHandler) HandleEcho(w http.ResponseWriter, req *http.Request) {
+	h.handleUnary(w, req, func(ctx context.Context, message *pb.Message, opts ...grpc.CallOption) (proto.Message, error) {
+		return h.client.GetReply(ctx, message, opts...)
+	})
+}
+
+// HandleInspect calls Inspect with the Message in the request body.
+func (h GatewayHandler) HandleInspect(w http.ResponseWriter, req *http.Request) {
+	h.handleUnary(w, req, func(ctx context.Context, message *pb.Message, opts ...grpc.CallOption) (proto.Message, error) {
+		return h.client.Inspect(ctx, message, opts...)
+	})
+}
+
+func (h GatewayHandler) handleUnary(w http.ResponseWriter, req *http.Request, call func(context.Context, *pb.Message, ...grpc.CallOption) (proto.Message, error)) {
+	message := &pb.Message{}
+	if err := readProtoJSON(w, req, message); err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	ctx, cancel, err := h.callContext(req)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	defer cancel()
+
+	var reply GatewayReply
+	response, err := call(ctx, message, grpc.Header(&reply.Headers), grpc.Trailer(&reply.Trailers))
+	if err == nil {
+		reply.Response, err = protojson.Marshal(response)
+	}
+
+	writeGatewayReply(w, reply, err)
+}
+
+// HandleServerStream calls ServerStream with the StreamMessage in the request
+// body and answers with every response once the stream is over.
+func (h GatewayHandler) HandleServerStream(w http.ResponseWriter, req *http.Request) {
+	message := &pb.StreamMessage{}
+	if err := readProtoJSON(w, req, message); err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	ctx, cancel, err := h.callContext(req)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	defer cancel()
+
+	stream, err := h.streamingClient.ServerStream(ctx, message)
+	if err != nil {
+		writeGatewayReply(w, GatewayReply{}, err)
+		return
+	}
+
+	reply, err := recvAll(stream)
+	writeGatewayReply(w, reply, err)
+}
+
+// HandleClientStream sends every message of the request body on a
+// ClientStream and answers with its single response.
+func (h GatewayHandler) HandleClientStream(w http.ResponseWriter, req *http.Request) {
+	messages, err := readStreamMessages(w, req)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	ctx, cancel, err := h.callContext(req)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	defer cancel()
+
+	stream, err := h.streamingClient.ClientStream(ctx)
+	if err != nil {
+		writeGatewayReply(w, GatewayReply{}, err)
+		return
+	}
+
+	var reply GatewayReply
+	for _, message := range messages {
+		// A failed Send is reported by CloseAndRecv with the real status.
+		if err := stream.Send(message); err != nil {
+			break
+		}
+	}
+
+	response, err := stream.CloseAndRecv()
+	reply.Headers, _ = stream.Header()
+	reply.Trailers = stream.Trailer()
+	if err == nil {
+		reply.Response, err = protojson.Marshal(response)
+	}
+
+	writeGatewayReply(w, reply, err)
+}
+
+// HandleBidirectional sends every message of the request body on a
+// BidirectionalStream, half-closes it and answers with every response.
+func (h GatewayHandler) HandleBidirectional(w http.ResponseWriter, req *http.Request) {
+	messages, err := readStreamMessages(w, req)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	ctx, cancel, err := h.callContext(req)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	defer cancel()
+
+	stream, err := h.streamingClient.BidirectionalStream(ctx)
+	if err != nil {
+		writeGatewayReply(w, GatewayReply{}, err)
+		return
+	}
+
+	go func() {
+		for _, message := range messages {
+			// A failed Send is reported by Recv with the real status.
+			if err := stream.Send(message); err != nil {
+				return
+			}
+		}
+		stream.CloseSend()
+	}()
+
+	reply, err := recvAll(stream)
+	writeGatewayReply(w, reply, err)
+}
+
+// callContext applies the request deadline and forwards the
+// Grpc-Metadata-* headers as outgoing metadata.
+func (h GatewayHandler) callContext(req *http.Request) (context.Context, context.CancelFunc, error) {
+	ctx, cancel, err := callContext(req, h.settings.GRPCDefaultTimeout)
+	if err != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	md := metadata.MD{}
+	for key, values := range req.Header {
+		if name, ok := strings.CutPrefix(key, metadataHeaderPrefix); ok && name != "" {
+			md.Append(name, values...)
+		}
+	}
+
+	return metadata.NewOutgoingContext(ctx, md), cancel, nil
+}
+
+type streamResponses interface {
+	Header() (metadata.MD, error)
+	Trailer() metadata.MD
+	Recv() (*pb.StreamResponse, error)
+}
+
+// recvAll reads stream until it ends and returns the responses, headers and
+// trailers. The error is nil when the stream ended with OK.
+func recvAll(stream streamResponses) (GatewayReply, error) {
+	var reply GatewayReply
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			reply.Headers, _ = stream.Header()
+			reply.Trailers = stream.Trailer()
+			return reply, err
+		}
+
+		data, err := protojson.Marshal(response)
+		if err != nil {
+			return reply, err
+		}
+		reply.Responses = append(reply.Responses, data)
+	}
+
+	reply.Headers, _ = stream.Header()
+	reply.Trailers = stream.Trailer()
+	return reply, nil
+}
+
+// maxBodySize bounds a gateway request body, like maxLineSize bounds a
+// single NDJSON message.
+const maxBodySize = maxLineSize
+
+// bodyReader limits the body of req to maxBodySize.
+func bodyReader(w http.ResponseWriter, req *http.Request) io.Reader {
+	return http.MaxBytesReader(w, req.Body, maxBodySize)
+}
+
+// bodyError converts the error of reading a body from bodyReader.
+func bodyError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return status.Errorf(codes.InvalidArgument, "request body is larger than %d bytes", tooLarge.Limit)
+	}
+	return status.Error(codes.InvalidArgument, "invalid request body: "+err.Error())
+}
+
+func readProtoJSON(w http.ResponseWriter, req *http.Request, message proto.Message) error {
+	data, err := io.ReadAll(bodyReader(w, req))
+	if err != nil {
+		return bodyError(err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	if err := protojson.Unmarshal(data, message); err != nil {
+		return status.Error(codes.InvalidArgument, "invalid request body: "+err.Error())
+	}
+	return nil
+}
+
+func readStreamMessages(w http.ResponseWriter, req *http.Request) ([]*pb.StreamMessage, error) {
+	var request GatewayStreamRequest
+	if err := json.NewDecoder(bodyReader(w, req)).Decode(&request); err != nil && err != io.EOF {
+		return nil, bodyError(err)
+	}
+
+	messages := make([]*pb.StreamMessage, 0, len(request.Messages))
+	for i, data := range request.Messages {
+		message := &pb.StreamMessage{}
+		if err := protojson.Unmarshal(data, message); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid message %d: %v", i, err)
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+// writeGatewayReply answers with the HTTP status matching err, and the
+// status, headers, trailers and responses received before it.
+func writeGatewayReply(w http.ResponseWriter, reply GatewayReply, err error) {
+	st := status.Convert(err)
+	if err != nil {
+		log.Info().Str("code", st.Code().String()).Msg(st.Message())
+	}
+	reply.Status = newStatusBody(st)
+
+	data, _ := json.Marshal(reply)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(HTTPStatusFromCode(st.Code()))
+	w.Write(data)
+}
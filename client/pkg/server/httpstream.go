@@ -0,0 +1,395 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// HTTPStreamHandler bridges the streaming RPCs to plain HTTP for tools and
+// proxies that handle chunked responses better than WebSocket upgrades:
+// Server-Sent Events for ServerStream and Health.Watch, and newline
+// delimited JSON bodies for ClientStream and BidirectionalStream. Messages
+// use the WSMessage and WSResponse shapes of the WebSocket routes.
+type HTTPStreamHandler struct {
+	streamingClient pb.StreamingServerClient
+	healthClient    healthpb.HealthClient
+}
+
+func NewHTTPStreamHandler(streamingClient pb.StreamingServerClient, healthClient healthpb.HealthClient) HTTPStreamHandler {
+	return HTTPStreamHandler{
+		streamingClient: streamingClient,
+		healthClient:    healthClient,
+	}
+}
+
+// HealthEvent is the data of every /sse/health event.
+type HealthEvent struct {
+	Service string `json:"service"`
+	Status  string `json:"status"`
+}
+
+// HandleServerStreamSSE starts a ServerStream and sends every response as a
+// "message" event, then an "end" event with the final status. The request is
+// a WSMessage in the JSON body or, for EventSource, in the query string:
+// /sse/stream/server?stream_id=1&message=hello&count=3&interval=1s.
+func (h HTTPStreamHandler) HandleServerStreamSSE(w http.ResponseWriter, req *http.Request) {
+	wsMsg, err := readSSEMessage(req)
+	if err != nil {
+		writeStatus(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	msg := &pb.StreamMessage{
+		StreamId:       wsMsg.StreamID,
+		SequenceNumber: wsMsg.SequenceNumber,
+		Timestamp:      wsMsg.Timestamp,
+		Message:        wsMsg.Message,
+		Count:          wsMsg.Count,
+		UntilCancelled: wsMsg.UntilCancelled,
+	}
+	if err := setStreamDurations(msg, wsMsg); err != nil {
+		writeStatus(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	// Streams are long lived, only an explicit timeout from the caller
+	// applies.
+	ctx, cancel, err := callContext(req, 0)
+	if err != nil {
+		writeStatus(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+	defer cancel()
+
+	stream, err := h.streamingClient.ServerStream(ctx, msg)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	events := newEventWriter(w)
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			events.end(err)
+			return
+		}
+
+		id := strconv.FormatInt(resp.SequenceNumber, 10)
+		if err := events.send("message", id, newWSResponse(resp)); err != nil {
+			return
+		}
+	}
+}
+
+// HandleHealthSSE watches the health of the service query parameter, the
+// overall server health when omitted, and sends every status change as a
+// "health" event until the caller disconnects.
+func (h HTTPStreamHandler) HandleHealthSSE(w http.ResponseWriter, req *http.Request) {
+	service := req.URL.Query().Get("service")
+
+	stream, err := h.healthClient.Watch(req.Context(), &healthpb.HealthCheckRequest{
+		Service: service,
+	})
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	events := newEventWriter(w)
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			events.end(err)
+			return
+		}
+
+		event := HealthEvent{
+			Service: service,
+			Status:  resp.Status.String(),
+		}
+		if err := events.send("health", "", event); err != nil {
+			return
+		}
+	}
+}
+
+// HandleClientStreamNDJSON sends every WSMessage line of the request body on
+// a ClientStream and answers with the summary as a single WSResponse line.
+func (h HTTPStreamHandler) HandleClientStreamNDJSON(w http.ResponseWriter, req *http.Request) {
+	ctx, cancel, err := callContext(req, 0)
+	if err != nil {
+		writeStatus(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+	defer cancel()
+
+	stream, err := h.streamingClient.ClientStream(ctx)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	// The whole body is read before answering, HTTP/1.1 closes it on the
+	// first write.
+	if err := readLines(req.Body, stream.Send); err != nil {
+		cancel()
+		writeStatus(w, err)
+		return
+	}
+
+	resp, err := stream.CloseAndRecv()
+	lines := newLineWriter(w)
+	if err != nil {
+		lines.end(err)
+		return
+	}
+
+	lines.send(newWSResponse(resp))
+	lines.end(nil)
+}
+
+// HandleBidirectionalNDJSON sends every WSMessage line of the request body
+// on a BidirectionalStream while writing every response as a WSResponse
+// line, so a caller on HTTP/2 (or an HTTP/1.1 client that keeps writing)
+// gets full duplex streaming.
+func (h HTTPStreamHandler) HandleBidirectionalNDJSON(w http.ResponseWriter, req *http.Request) {
+	if req.ProtoMajor == 1 {
+		// Read the body while responding, the default for HTTP/1.1 is to
+		// close it on the first write.
+		http.NewResponseController(w).EnableFullDuplex()
+	}
+
+	ctx, cancel, err := callContext(req, 0)
+	if err != nil {
+		writeStatus(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+	defer cancel()
+
+	stream, err := h.streamingClient.BidirectionalStream(ctx)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	sendErrs := make(chan error, 1)
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		err := readLines(req.Body, stream.Send)
+		sendErrs <- err
+		if err != nil {
+			cancel()
+			return
+		}
+		stream.CloseSend()
+	}()
+
+	// The body must not be read once the handler returns. Cancelling
+	// unblocks a Send and the read deadline a Read still waiting for the
+	// caller.
+	defer func() {
+		cancel()
+		http.NewResponseController(w).SetReadDeadline(time.Now())
+		<-readDone
+	}()
+
+	lines := newLineWriter(w)
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			lines.end(nil)
+			return
+		}
+		if err != nil {
+			// An invalid line cancels the stream, report it rather than
+			// the cancellation.
+			select {
+			case sendErr := <-sendErrs:
+				if sendErr != nil {
+					err = sendErr
+				}
+			default:
+			}
+			lines.end(err)
+			return
+		}
+
+		if err := lines.send(newWSResponse(resp)); err != nil {
+			return
+		}
+	}
+}
+
+// maxLineSize bounds a single NDJSON message.
+const maxLineSize = 4 * 1024 * 1024
+
+// readLines decodes every line of body as a WSMessage and passes it to send
+// until the body ends. An invalid line is returned as InvalidArgument, a gRPC
+// send error is not returned as the stream reports it with its final status.
+func readLines(body io.Reader, send func(*pb.StreamMessage) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var wsMsg WSMessage
+		if err := json.Unmarshal(scanner.Bytes(), &wsMsg); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid message on line %d: %v", line, err)
+		}
+
+		msg := &pb.StreamMessage{
+			StreamId:       wsMsg.StreamID,
+			SequenceNumber: wsMsg.SequenceNumber,
+			Timestamp:      wsMsg.Timestamp,
+			Message:        wsMsg.Message,
+		}
+		if err := send(msg); err != nil {
+			return nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to read body: %v", err)
+	}
+	return nil
+}
+
+// readSSEMessage reads the WSMessage of an SSE request from the JSON body of
+// a POST or the query string of a GET.
+func readSSEMessage(req *http.Request) (WSMessage, error) {
+	var wsMsg WSMessage
+	if req.Method == http.MethodPost {
+		if err := json.NewDecoder(req.Body).Decode(&wsMsg); err != nil {
+			return wsMsg, fmt.Errorf("invalid message format: %w", err)
+		}
+		return wsMsg, nil
+	}
+
+	query := req.URL.Query()
+	wsMsg.StreamID = query.Get("stream_id")
+	wsMsg.Message = query.Get("message")
+	wsMsg.Interval = query.Get("interval")
+	wsMsg.Jitter = query.Get("jitter")
+
+	if value := query.Get("count"); value != "" {
+		count, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return wsMsg, fmt.Errorf("invalid count: %w", err)
+		}
+		wsMsg.Count = int32(count)
+	}
+
+	if value := query.Get("until_cancelled"); value != "" {
+		untilCancelled, err := strconv.ParseBool(value)
+		if err != nil {
+			return wsMsg, fmt.Errorf("invalid until_cancelled: %w", err)
+		}
+		wsMsg.UntilCancelled = untilCancelled
+	}
+
+	return wsMsg, nil
+}
+
+// eventWriter writes Server-Sent Events and flushes each one.
+type eventWriter struct {
+	w       http.ResponseWriter
+	flusher *http.ResponseController
+}
+
+func newEventWriter(w http.ResponseWriter) eventWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Ask nginx style proxies not to buffer the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	events := eventWriter{w: w, flusher: http.NewResponseController(w)}
+	events.flusher.Flush()
+	return events
+}
+
+func (e eventWriter) send(event, id string, v interface{}) error {
+	data, _ := json.Marshal(v)
+
+	if id != "" {
+		fmt.Fprintf(e.w, "id: %s\n", id)
+	}
+	if _, err := fmt.Fprintf(e.w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	return e.flusher.Flush()
+}
+
+// end sends the final status of the stream as an "end" event, or an "error"
+// event when it failed. Nothing is sent once the caller went away.
+func (e eventWriter) end(err error) {
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	if status.Code(err) == codes.Canceled {
+		return
+	}
+
+	st := status.Convert(err)
+	event := "end"
+	if err != nil {
+		log.Info().Str("code", st.Code().String()).Msg(st.Message())
+		event = "error"
+	}
+	e.send(event, "", newStatusBody(st))
+}
+
+// lineWriter writes newline delimited JSON and flushes each line. The final
+// gRPC status is sent in the Grpc-Status and Grpc-Message trailers.
+type lineWriter struct {
+	w       http.ResponseWriter
+	flusher *http.ResponseController
+}
+
+func newLineWriter(w http.ResponseWriter) lineWriter {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+
+	lines := lineWriter{w: w, flusher: http.NewResponseController(w)}
+	lines.flusher.Flush()
+	return lines
+}
+
+func (l lineWriter) send(v interface{}) error {
+	data, _ := json.Marshal(v)
+	if _, err := l.w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return l.flusher.Flush()
+}
+
+// end sets the trailers and, when the stream failed, sends a last WSError
+// line.
+func (l lineWriter) end(err error) {
+	st := status.Convert(err)
+	if err != nil {
+		log.Info().Str("code", st.Code().String()).Msg(st.Message())
+		l.send(newWSError(err))
+	}
+
+	l.w.Header().Set("Grpc-Status", strconv.Itoa(int(st.Code())))
+	l.w.Header().Set("Grpc-Message", encodeGrpcMessage(st.Message()))
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/loadgen"
+)
+
+type LoadTestHandler struct {
+	runner *loadgen.Runner
+}
+
+func NewLoadTestHandler(runner *loadgen.Runner) LoadTestHandler {
+	return LoadTestHandler{
+		runner: runner,
+	}
+}
+
+// Handle runs a load test described by the JSON body, any omitted field
+// keeps its default, and answers with the report once the run is over.
+func (h LoadTestHandler) Handle(w http.ResponseWriter, req *http.Request) {
+	config := loadgen.DefaultConfig()
+	if req.ContentLength != 0 {
+		if err := json.NewDecoder(req.Body).Decode(&config); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid load test config: " + err.Error()))
+			return
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	log.Info().
+		Str("rpc", config.RPC).
+		Float64("rate", config.Rate).
+		Int("concurrency", config.Concurrency).
+		Str("duration", config.Duration.String()).
+		Msg("load test: starting")
+
+	report, err := h.runner.Run(req.Context(), config)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	log.Info().
+		Int("requests", report.Requests).
+		Int("errors", report.Errors).
+		Float64("throughput", report.Throughput).
+		Msg("load test: completed")
+
+	data, _ := json.Marshal(report)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
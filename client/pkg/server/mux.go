@@ -0,0 +1,400 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/session"
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Operations of a MuxFrame.
+const (
+	MuxOpen      = "open"
+	MuxSend      = "send"
+	MuxHalfClose = "half_close"
+	MuxCancel    = "cancel"
+)
+
+// RPCs a MuxFrame can open.
+const (
+	MuxUnary         = "unary"
+	MuxServer        = "server"
+	MuxClient        = "client"
+	MuxBidirectional = "bidirectional"
+)
+
+// Types of a MuxEvent.
+const (
+	MuxOpened  = "opened"
+	MuxMessage = "message"
+	MuxStatus  = "status"
+	MuxError   = "error"
+)
+
+// MuxFrame is a frame sent by the caller on /ws/mux. open starts the RPC
+// of stream_id, with Message as the request of unary and server streams or
+// the first message of client and bidirectional streams. send adds a
+// message, half_close ends the input and cancel cancels the RPC.
+type MuxFrame struct {
+	Op       string     `json:"op"`
+	StreamID string     `json:"stream_id,omitempty"`
+	RPC      string     `json:"rpc,omitempty"`
+	Message  *WSMessage `json:"message,omitempty"`
+}
+
+// MuxEvent is a frame sent to the caller on /ws/mux. opened confirms an open
+// with the stream_id, assigned when the caller left it empty; message carries
+// a response; status ends the stream; error rejects a frame without ending
+// the stream.
+type MuxEvent struct {
+	Type     string      `json:"type"`
+	StreamID string      `json:"stream_id,omitempty"`
+	Message  *WSResponse `json:"message,omitempty"`
+	Status   *statusBody `json:"status,omitempty"`
+	Error    *statusBody `json:"error,omitempty"`
+}
+
+// muxStream is a logical stream of a mux session.
+type muxStream struct {
+	id     string
+	rpc    string
+	cancel context.CancelFunc
+
+	// input feeds client and bidirectional streams, closed on half_close.
+	// Only the read loop of the session sends on and closes it.
+	input      chan *pb.StreamMessage
+	halfClosed bool
+}
+
+// muxSession runs the logical streams of one /ws/mux connection.
+type muxSession struct {
+	h       *WebSocketHandler
+	session *session.Session
+	ctx     context.Context
+
+	mu      sync.Mutex
+	streams map[string]*muxStream
+	running sync.WaitGroup
+}
+
+// HandleMux multiplexes many unary and streaming RPCs over one WebSocket,
+// each identified by the stream_id of its frames.
+func (h *WebSocketHandler) HandleMux(w http.ResponseWriter, r *http.Request) {
+	s, closeSession, err := h.open(w, r, "mux", nil)
+	if err != nil {
+		return
+	}
+	defer closeSession()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	m := &muxSession{
+		h:       h,
+		session: s,
+		ctx:     ctx,
+		streams: make(map[string]*muxStream),
+	}
+
+	// Cancel every stream still running once the socket is gone, and wait
+	// for them so their last events are queued before the close frame.
+	defer m.running.Wait()
+	defer cancel()
+
+	for {
+		_, message, err := s.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var frame MuxFrame
+		if err := json.Unmarshal(message, &frame); err != nil {
+			m.reject("", invalidMessage(err))
+			continue
+		}
+
+		if err := m.handle(frame); err != nil {
+			m.reject(frame.StreamID, err)
+		}
+	}
+}
+
+func (m *muxSession) handle(frame MuxFrame) error {
+	if frame.Op == MuxOpen {
+		return m.open(frame)
+	}
+
+	m.mu.Lock()
+	stream, ok := m.streams[frame.StreamID]
+	m.mu.Unlock()
+	if !ok {
+		return status.Errorf(codes.NotFound, "stream %q is not open", frame.StreamID)
+	}
+
+	switch frame.Op {
+	case MuxSend:
+		if stream.input == nil {
+			return status.Errorf(codes.FailedPrecondition, "%s streams take no messages after open", stream.rpc)
+		}
+		if frame.Message == nil {
+			return status.Error(codes.InvalidArgument, "message is required")
+		}
+		return m.send(stream, frame.Message)
+
+	case MuxHalfClose:
+		if stream.input == nil {
+			return status.Errorf(codes.FailedPrecondition, "%s streams are half-closed on open", stream.rpc)
+		}
+		if !stream.halfClosed {
+			stream.halfClosed = true
+			close(stream.input)
+		}
+		return nil
+
+	case MuxCancel:
+		stream.cancel()
+		return nil
+
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown op %q, must be open, send, half_close or cancel", frame.Op)
+	}
+}
+
+func (m *muxSession) open(frame MuxFrame) error {
+	switch frame.RPC {
+	case MuxUnary, MuxServer:
+		if frame.Message == nil {
+			return status.Errorf(codes.InvalidArgument, "message is required to open a %s stream", frame.RPC)
+		}
+	case MuxClient, MuxBidirectional:
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown rpc %q, must be unary, server, client or bidirectional", frame.RPC)
+	}
+
+	if frame.StreamID == "" {
+		frame.StreamID = uuid.New().String()
+	}
+
+	m.mu.Lock()
+	if _, ok := m.streams[frame.StreamID]; ok {
+		m.mu.Unlock()
+		return status.Errorf(codes.AlreadyExists, "stream %q is already open", frame.StreamID)
+	}
+	if len(m.streams) >= m.h.settings.WSMuxMaxStreams {
+		m.mu.Unlock()
+		return status.Errorf(codes.ResourceExhausted, "at most %d streams can be open", m.h.settings.WSMuxMaxStreams)
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	stream := &muxStream{
+		id:     frame.StreamID,
+		rpc:    frame.RPC,
+		cancel: cancel,
+	}
+	if frame.RPC == MuxClient || frame.RPC == MuxBidirectional {
+		// Room for at least the first message, queued below before the
+		// stream runs.
+		stream.input = make(chan *pb.StreamMessage, max(m.h.config.QueueSize, 1))
+	}
+	m.streams[stream.id] = stream
+	m.mu.Unlock()
+
+	m.session.Send(MuxEvent{Type: MuxOpened, StreamID: stream.id})
+
+	var run func(context.Context, *muxStream, *WSMessage) error
+	switch frame.RPC {
+	case MuxUnary:
+		run = m.runUnary
+	case MuxServer:
+		run = m.runServer
+	case MuxClient:
+		run = m.runClient
+	case MuxBidirectional:
+		run = m.runBidirectional
+	}
+
+	if frame.Message != nil && stream.input != nil {
+		// Cannot block, the input is empty and buffered.
+		stream.input <- streamMessage(stream.id, frame.Message)
+	}
+
+	m.running.Add(1)
+	go func() {
+		defer m.running.Done()
+		defer cancel()
+
+		err := run(ctx, stream, frame.Message)
+		m.finish(stream, err)
+	}()
+
+	return nil
+}
+
+// send queues msg on the input of stream. A full input rejects the message
+// rather than stalling the other streams of the session.
+func (m *muxSession) send(stream *muxStream, msg *WSMessage) error {
+	if stream.halfClosed {
+		return status.Errorf(codes.FailedPrecondition, "stream %q is half-closed", stream.id)
+	}
+
+	select {
+	case stream.input <- streamMessage(stream.id, msg):
+		return nil
+	default:
+		return status.Errorf(codes.ResourceExhausted, "stream %q input is full", stream.id)
+	}
+}
+
+// finish removes stream and sends its final status.
+func (m *muxSession) finish(stream *muxStream, err error) {
+	m.mu.Lock()
+	delete(m.streams, stream.id)
+	m.mu.Unlock()
+
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	body := newStatusBody(status.Convert(err))
+	m.session.Send(MuxEvent{Type: MuxStatus, StreamID: stream.id, Status: &body})
+}
+
+// reject sends err as an error event for a frame of streamID.
+func (m *muxSession) reject(streamID string, err error) {
+	body := newStatusBody(status.Convert(err))
+	m.session.Send(MuxEvent{Type: MuxError, StreamID: streamID, Error: &body})
+}
+
+func (m *muxSession) message(stream *muxStream, resp WSResponse) error {
+	return m.session.Send(MuxEvent{Type: MuxMessage, StreamID: stream.id, Message: &resp})
+}
+
+func (m *muxSession) runUnary(ctx context.Context, stream *muxStream, msg *WSMessage) error {
+	if timeout := m.h.settings.GRPCDefaultTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	reply, err := m.h.client.GetReply(ctx, &pb.Message{Message: msg.Message})
+	if err != nil {
+		return err
+	}
+
+	return m.message(stream, WSResponse{
+		StreamID:            stream.id,
+		Response:            reply.Response,
+		Success:             reply.Success,
+		RequestEncoding:     reply.RequestEncoding,
+		ResponseEncoding:    reply.ResponseEncoding,
+		PreviousRPCAttempts: reply.PreviousRpcAttempts,
+	})
+}
+
+func (m *muxSession) runServer(ctx context.Context, stream *muxStream, msg *WSMessage) error {
+	request := streamMessage(stream.id, msg)
+	request.Count = msg.Count
+	request.UntilCancelled = msg.UntilCancelled
+	if err := setStreamDurations(request, *msg); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	client, err := m.h.streamingClient.ServerStream(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	for {
+		resp, err := client.Recv()
+		if err != nil {
+			return err
+		}
+		if err := m.message(stream, newWSResponse(resp)); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *muxSession) runClient(ctx context.Context, stream *muxStream, _ *WSMessage) error {
+	client, err := m.h.streamingClient.ClientStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case msg, ok := <-stream.input:
+			if !ok {
+				resp, err := client.CloseAndRecv()
+				if err != nil {
+					return err
+				}
+				return m.message(stream, newWSResponse(resp))
+			}
+			// A failed Send is reported by CloseAndRecv with the real
+			// status.
+			if err := client.Send(msg); err != nil {
+				_, err := client.CloseAndRecv()
+				return err
+			}
+		}
+	}
+}
+
+func (m *muxSession) runBidirectional(ctx context.Context, stream *muxStream, _ *WSMessage) error {
+	client, err := m.h.streamingClient.BidirectionalStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-stream.input:
+				if !ok {
+					client.CloseSend()
+					return
+				}
+				// A failed Send is reported by Recv with the real status.
+				if err := client.Send(msg); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		resp, err := client.Recv()
+		if err != nil {
+			return err
+		}
+		if err := m.message(stream, newWSResponse(resp)); err != nil {
+			return err
+		}
+	}
+}
+
+// streamMessage converts msg, defaulting its stream_id to the mux stream_id
+// as the server rejects an empty one.
+func streamMessage(streamID string, msg *WSMessage) *pb.StreamMessage {
+	request := &pb.StreamMessage{
+		StreamId:       msg.StreamID,
+		SequenceNumber: msg.SequenceNumber,
+		Timestamp:      msg.Timestamp,
+		Message:        msg.Message,
+	}
+	if request.StreamId == "" {
+		request.StreamId = streamID
+	}
+
+	return request
+}
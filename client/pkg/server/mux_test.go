@@ -0,0 +1,242 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/session"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/settings"
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeStreamingServer answers client streams with the number of messages
+// received, echoes bidirectional streams and sends Count responses on server
+// streams.
+type fakeStreamingServer struct {
+	pb.UnimplementedStreamingServerServer
+}
+
+func (fakeStreamingServer) ClientStream(stream grpc.ClientStreamingServer[pb.StreamMessage, pb.StreamResponse]) error {
+	received := 0
+	for {
+		_, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(&pb.StreamResponse{
+				Response: fmt.Sprintf("received %d messages", received),
+				Success:  true,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		received++
+	}
+}
+
+func (fakeStreamingServer) ServerStream(msg *pb.StreamMessage, stream grpc.ServerStreamingServer[pb.StreamResponse]) error {
+	for i := int64(1); i <= int64(msg.Count); i++ {
+		if err := stream.Send(&pb.StreamResponse{StreamId: msg.StreamId, SequenceNumber: i, Response: msg.Message}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fakeStreamingServer) BidirectionalStream(stream grpc.BidiStreamingServer[pb.StreamMessage, pb.StreamResponse]) error {
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&pb.StreamResponse{StreamId: msg.StreamId, Response: "echo: " + msg.Message}); err != nil {
+			return err
+		}
+	}
+}
+
+// muxClient is the caller side of a /ws/mux socket. Events read while
+// waiting for another one are kept for later expectations.
+type muxClient struct {
+	t       *testing.T
+	conn    *websocket.Conn
+	pending []MuxEvent
+}
+
+// newMuxClient serves /ws/mux backed by fakeStreamingServer and connects
+// to it.
+func newMuxClient(t *testing.T, queueSize, maxStreams int) *muxClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	pb.RegisterStreamingServerServer(grpcServer, fakeStreamingServer{})
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	cc, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+
+	handler := NewWebSocketHandler(settings.Settings{WSMuxMaxStreams: maxStreams}, pb.NewServerClient(cc), pb.NewStreamingServerClient(cc), session.Config{
+		QueueSize:    queueSize,
+		Overflow:     session.Block,
+		PingInterval: time.Minute,
+		PongWait:     2 * time.Minute,
+		WriteWait:    time.Second,
+	})
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleMux))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &muxClient{t: t, conn: conn}
+}
+
+func (c *muxClient) send(frame MuxFrame) {
+	c.t.Helper()
+
+	if err := c.conn.WriteJSON(frame); err != nil {
+		c.t.Fatalf("failed to send %+v: %v", frame, err)
+	}
+}
+
+// expect returns the next event of streamID with type eventType.
+func (c *muxClient) expect(streamID, eventType string) MuxEvent {
+	c.t.Helper()
+
+	return c.next(func(event MuxEvent) bool {
+		return event.StreamID == streamID && event.Type == eventType
+	})
+}
+
+// next returns the next event matching match.
+func (c *muxClient) next(match func(MuxEvent) bool) MuxEvent {
+	c.t.Helper()
+
+	for i, event := range c.pending {
+		if match(event) {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return event
+		}
+	}
+
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var event MuxEvent
+		if err := c.conn.ReadJSON(&event); err != nil {
+			c.t.Fatalf("expected event not received: %v", err)
+		}
+		if match(event) {
+			return event
+		}
+		c.pending = append(c.pending, event)
+	}
+}
+
+// expectCode waits for the next error or status event of streamID and checks
+// its code.
+func (c *muxClient) expectCode(streamID, eventType string, want codes.Code) {
+	c.t.Helper()
+
+	event := c.expect(streamID, eventType)
+	body := event.Status
+	if eventType == MuxError {
+		body = event.Error
+	}
+	if body == nil || body.Code != want {
+		c.t.Fatalf("%s event of stream %q = %+v, want code %s", eventType, streamID, body, want)
+	}
+}
+
+func TestMuxClientStreamWithoutQueue(t *testing.T) {
+	c := newMuxClient(t, 0, 10)
+
+	c.send(MuxFrame{Op: MuxOpen, StreamID: "c", RPC: MuxClient, Message: &WSMessage{Message: "first"}})
+	c.expect("c", MuxOpened)
+	c.send(MuxFrame{Op: MuxHalfClose, StreamID: "c"})
+
+	if got := c.expect("c", MuxMessage).Message.Response; got != "received 1 messages" {
+		t.Errorf("response = %q, want received 1 messages", got)
+	}
+	c.expectCode("c", MuxStatus, codes.OK)
+}
+
+func TestMuxStreams(t *testing.T) {
+	c := newMuxClient(t, 4, 3)
+
+	// An open without stream_id gets one.
+	c.send(MuxFrame{Op: MuxOpen, RPC: MuxBidirectional})
+	id := c.next(func(event MuxEvent) bool { return event.Type == MuxOpened }).StreamID
+	if id == "" {
+		t.Fatal("opened event without stream_id")
+	}
+
+	c.send(MuxFrame{Op: MuxSend, StreamID: id, Message: &WSMessage{Message: "one"}})
+	if got := c.expect(id, MuxMessage).Message.Response; got != "echo: one" {
+		t.Errorf("response = %q, want echo: one", got)
+	}
+
+	c.send(MuxFrame{Op: MuxOpen, StreamID: id, RPC: MuxBidirectional})
+	c.expectCode(id, MuxError, codes.AlreadyExists)
+
+	c.send(MuxFrame{Op: MuxOpen, StreamID: "s", RPC: MuxServer, Message: &WSMessage{Message: "tick", Count: 2}})
+	c.expect("s", MuxOpened)
+	for want := int64(1); want <= 2; want++ {
+		if got := c.expect("s", MuxMessage).Message.SequenceNumber; got != want {
+			t.Errorf("sequence number = %d, want %d", got, want)
+		}
+	}
+	c.expectCode("s", MuxStatus, codes.OK)
+
+	c.send(MuxFrame{Op: MuxOpen, StreamID: "c1", RPC: MuxClient})
+	c.expect("c1", MuxOpened)
+	c.send(MuxFrame{Op: MuxOpen, StreamID: "c2", RPC: MuxClient})
+	c.expect("c2", MuxOpened)
+	c.send(MuxFrame{Op: MuxOpen, StreamID: "c3", RPC: MuxClient})
+	c.expectCode("c3", MuxError, codes.ResourceExhausted)
+
+	c.send(MuxFrame{Op: MuxCancel, StreamID: "c1"})
+	c.expectCode("c1", MuxStatus, codes.Canceled)
+
+	c.send(MuxFrame{Op: MuxSend, StreamID: "missing", Message: &WSMessage{}})
+	c.expectCode("missing", MuxError, codes.NotFound)
+	c.send(MuxFrame{Op: MuxOpen, StreamID: "u", RPC: MuxUnary})
+	c.expectCode("u", MuxError, codes.InvalidArgument)
+	c.send(MuxFrame{Op: "close", StreamID: id})
+	c.expectCode(id, MuxError, codes.InvalidArgument)
+
+	c.send(MuxFrame{Op: MuxHalfClose, StreamID: "c2"})
+	if got := c.expect("c2", MuxMessage).Message.Response; got != "received 0 messages" {
+		t.Errorf("response = %q, want received 0 messages", got)
+	}
+	c.expectCode("c2", MuxStatus, codes.OK)
+
+	c.send(MuxFrame{Op: MuxHalfClose, StreamID: id})
+	c.expectCode(id, MuxStatus, codes.OK)
+}
@@ -4,53 +4,141 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"github.com/rs/zerolog/log"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/lb"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/loadgen"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/metrics"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/session"
 	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/settings"
 	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
 )
 
-var ctx = context.Background()
-
 type Server struct {
 	settings        settings.Settings
 	client          pb.ServerClient
 	streamingClient pb.StreamingServerClient
+	adminClient     pb.AdminClient
+	healthClient    healthpb.HealthClient
+	tracker         *lb.Tracker
+	wsHandler       *WebSocketHandler
+	httpServer      *http.Server
+	draining        atomic.Bool
 }
 
-func NewServer(settings settings.Settings, client pb.ServerClient, streamingClient pb.StreamingServerClient) Server {
-	return Server{
+func NewServer(settings settings.Settings, client pb.ServerClient, streamingClient pb.StreamingServerClient, adminClient pb.AdminClient, healthClient healthpb.HealthClient, tracker *lb.Tracker, wsConfig session.Config) *Server {
+	e := &Server{
 		settings:        settings,
 		client:          client,
 		streamingClient: streamingClient,
+		adminClient:     adminClient,
+		healthClient:    healthClient,
+		tracker:         tracker,
+		wsHandler:       NewWebSocketHandler(settings, client, streamingClient, wsConfig),
+	}
+
+	// h2c lets the NDJSON routes stream both ways over cleartext HTTP/2.
+	e.httpServer = &http.Server{
+		Addr:    ":" + settings.HTTPPort,
+		Handler: h2c.NewHandler(e.router(), &http2.Server{}),
 	}
+
+	return e
+}
+
+// ServeHTTP blocks until the HTTP server fails or Shutdown is called, in
+// which case it returns http.ErrServerClosed.
+func (e *Server) ServeHTTP() error {
+	return e.httpServer.ListenAndServe()
+}
+
+// Drain makes /readyz fail so load balancers stop routing new requests to
+// this instance.
+func (e *Server) Drain() {
+	e.draining.Store(true)
 }
 
-func (e Server) ServeHTTP() {
+// Shutdown stops accepting connections, closes every WebSocket session with
+// 1001 "going away" and waits for in-flight requests until ctx is done.
+func (e *Server) Shutdown(ctx context.Context) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- e.httpServer.Shutdown(ctx)
+	}()
+
+	e.wsHandler.CloseAll(ctx)
+	return <-errs
+}
+
+func (e *Server) router() http.Handler {
 	handler := NewHandler(e.settings, e.client)
 
 	r := mux.NewRouter()
+	r.Use(tracingMiddleware)
 
 	r.HandleFunc("/grpc/{key}", handler.Handle)
-	wsHandler := NewWebSocketHandler(e.streamingClient)
-	r.HandleFunc("/ws/stream/bidirectional", wsHandler.HandleBidirectional)
-	r.HandleFunc("/ws/stream/server", wsHandler.HandleServerStream)
-	r.HandleFunc("/ws/stream/client", wsHandler.HandleClientStream)
+	r.HandleFunc("/inspect/{key}", handler.HandleInspect)
+	gatewayHandler := NewGatewayHandler(e.settings, e.client, e.streamingClient)
+	r.HandleFunc("/v1/echo", gatewayHandler.HandleEcho).Methods(http.MethodPost)
+	r.HandleFunc("/v1/inspect", gatewayHandler.HandleInspect).Methods(http.MethodPost)
+	r.HandleFunc("/v1/stream/server", gatewayHandler.HandleServerStream).Methods(http.MethodPost)
+	r.HandleFunc("/v1/stream/client", gatewayHandler.HandleClientStream).Methods(http.MethodPost)
+	r.HandleFunc("/v1/stream/bidirectional", gatewayHandler.HandleBidirectional).Methods(http.MethodPost)
+	r.HandleFunc("/ws/stream/bidirectional", e.wsHandler.HandleBidirectional)
+	r.HandleFunc("/ws/stream/server", e.wsHandler.HandleServerStream)
+	r.HandleFunc("/ws/stream/client", e.wsHandler.HandleClientStream)
+	r.HandleFunc("/ws/mux", e.wsHandler.HandleMux)
+	r.HandleFunc("/ws/sessions", e.wsHandler.HandleSessions).Methods(http.MethodGet)
+	httpStreamHandler := NewHTTPStreamHandler(e.streamingClient, e.healthClient)
+	r.HandleFunc("/sse/stream/server", httpStreamHandler.HandleServerStreamSSE).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/sse/health", httpStreamHandler.HandleHealthSSE).Methods(http.MethodGet)
+	r.HandleFunc("/ndjson/stream/client", httpStreamHandler.HandleClientStreamNDJSON).Methods(http.MethodPost)
+	r.HandleFunc("/ndjson/stream/bidirectional", httpStreamHandler.HandleBidirectionalNDJSON).Methods(http.MethodPost)
+	adminHandler := NewAdminHandler(e.adminClient)
+	r.HandleFunc("/admin/health", adminHandler.HandleList).Methods(http.MethodGet)
+	r.HandleFunc("/admin/health", adminHandler.HandleSet).Methods(http.MethodPost, http.MethodPut)
+	loadTestHandler := NewLoadTestHandler(loadgen.NewRunner(e.client, e.streamingClient))
+	r.HandleFunc("/loadtest", loadTestHandler.Handle).Methods(http.MethodPost)
+	r.HandleFunc("/backends", NewBackendsHandler(e.tracker).Handle).Methods(http.MethodGet)
+	r.Handle("/metrics", metrics.Handler())
 	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("Hello!"))
 	})
 	r.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if e.draining.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("Draining!"))
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("Hello!"))
 	})
 
-	err := http.ListenAndServe(":"+e.settings.HTTPPort, r)
-	if err != nil {
-		log.Fatal().Err(err)
-	}
+	return r
+}
+
+// tracingMiddleware starts a server span named after the matched route and
+// extracts the caller's trace context, so gRPC calls made with the request
+// context join the caller's trace.
+func tracingMiddleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "", otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+		if route := mux.CurrentRoute(req); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				return req.Method + " " + template
+			}
+		}
+		return req.Method
+	}))
 }
 
 type Handler struct {
@@ -69,16 +157,49 @@ func (h Handler) Handle(w http.ResponseWriter, req *http.Request) {
 	key := uuid.New().String()
 	value := mux.Vars(req)["key"]
 
+	ctx, cancel, err := callContext(req, h.settings.GRPCDefaultTimeout)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	defer cancel()
+
+	var p peer.Peer
 	reply, err := h.client.GetReply(ctx, &pb.Message{
 		Message: value,
-	})
+	}, grpc.Peer(&p))
+	if p.Addr != nil {
+		w.Header().Set("X-Echo-Backend", p.Addr.String())
+	}
 	if err != nil {
-		log.Info().Msg(err.Error())
-		w.WriteHeader(http.StatusBadGateway)
-		w.Write([]byte(err.Error()))
+		writeStatus(w, err)
 		return
 	}
 
+	w.Header().Set("X-Echo-Previous-RPC-Attempts", strconv.Itoa(int(reply.PreviousRpcAttempts)))
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(key + ":" + reply.Response + fmt.Sprintf(":success:%v", reply.Success)))
 }
+
+func (h Handler) HandleInspect(w http.ResponseWriter, req *http.Request) {
+	value := mux.Vars(req)["key"]
+
+	ctx, cancel, err := callContext(req, h.settings.GRPCDefaultTimeout)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	defer cancel()
+
+	reply, err := h.client.Inspect(ctx, &pb.Message{
+		Message: value,
+	})
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	writeProtoJSON(w, reply)
+}
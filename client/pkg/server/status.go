@@ -0,0 +1,149 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// HTTPStatusFromCode maps a gRPC status code to the canonical HTTP status, as
+// documented in google/rpc/code.proto.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		// Client Closed Request, non standard but what proxies use.
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// closeCodeBase is added to a gRPC code to build a WebSocket close code in
+// the 4000-4999 range RFC 6455 leaves to applications, e.g. 4014 for
+// UNAVAILABLE.
+const closeCodeBase = 4000
+
+// maxCloseReason is the longest reason a close frame can carry.
+const maxCloseReason = 123
+
+// WebSocketCloseCodeFromCode maps a gRPC status code to the close code of a
+// WebSocket stream: 1000 for OK and 4000 plus the code otherwise.
+func WebSocketCloseCodeFromCode(code codes.Code) int {
+	if code == codes.OK {
+		return websocket.CloseNormalClosure
+	}
+	return closeCodeBase + int(code)
+}
+
+// closeReason returns the reason of a close frame for st, such as
+// "UNAVAILABLE: connection refused", cut to fit a control frame.
+func closeReason(st *status.Status) string {
+	if st.Code() == codes.OK {
+		return ""
+	}
+
+	reason := codeName(st.Code())
+	if st.Message() != "" {
+		reason += ": " + st.Message()
+	}
+	for len(reason) > maxCloseReason || !utf8.ValidString(reason) {
+		reason = reason[:len(reason)-1]
+	}
+	return reason
+}
+
+type statusBody struct {
+	Code    codes.Code        `json:"code"`
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Details []json.RawMessage `json:"details,omitempty"`
+}
+
+// writeStatus answers with the HTTP status matching the gRPC error and the
+// status code, message and details as JSON.
+func writeStatus(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	log.Info().Str("code", st.Code().String()).Msg(st.Message())
+
+	data, _ := json.Marshal(newStatusBody(st))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(HTTPStatusFromCode(st.Code()))
+	w.Write(data)
+}
+
+func newStatusBody(st *status.Status) statusBody {
+	body := statusBody{
+		Code:    st.Code(),
+		Status:  codeName(st.Code()),
+		Message: st.Message(),
+	}
+
+	for _, detail := range st.Proto().GetDetails() {
+		data, err := protojson.Marshal(detail)
+		if err != nil {
+			// Unknown detail type, keep the raw bytes.
+			data, _ = json.Marshal(map[string]interface{}{
+				"@type": detail.GetTypeUrl(),
+				"value": detail.GetValue(),
+			})
+		}
+		body.Details = append(body.Details, data)
+	}
+
+	return body
+}
+
+// codeName returns the canonical upper snake case name, e.g. UNAVAILABLE.
+func codeName(c codes.Code) string {
+	if name, ok := code.Code_name[int32(c)]; ok {
+		return name
+	}
+	return c.String()
+}
+
+// encodeGrpcMessage percent-encodes msg for the grpc-message header the way
+// grpc-go does: printable ASCII other than '%' is kept, every other byte of
+// the UTF-8 encoding becomes %XX and invalid UTF-8 is replaced by U+FFFD.
+func encodeGrpcMessage(msg string) string {
+	var sb strings.Builder
+	for _, r := range msg {
+		if r >= ' ' && r <= '~' && r != '%' {
+			sb.WriteRune(r)
+			continue
+		}
+		for _, b := range []byte(string(r)) {
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHTTPStatusFromCode(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want int
+	}{
+		{codes.OK, http.StatusOK},
+		{codes.Canceled, 499},
+		{codes.Unknown, http.StatusInternalServerError},
+		{codes.InvalidArgument, http.StatusBadRequest},
+		{codes.DeadlineExceeded, http.StatusGatewayTimeout},
+		{codes.NotFound, http.StatusNotFound},
+		{codes.AlreadyExists, http.StatusConflict},
+		{codes.PermissionDenied, http.StatusForbidden},
+		{codes.ResourceExhausted, http.StatusTooManyRequests},
+		{codes.FailedPrecondition, http.StatusBadRequest},
+		{codes.Aborted, http.StatusConflict},
+		{codes.OutOfRange, http.StatusBadRequest},
+		{codes.Unimplemented, http.StatusNotImplemented},
+		{codes.Internal, http.StatusInternalServerError},
+		{codes.Unavailable, http.StatusServiceUnavailable},
+		{codes.DataLoss, http.StatusInternalServerError},
+		{codes.Unauthenticated, http.StatusUnauthorized},
+		{codes.Code(42), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			if got := HTTPStatusFromCode(tt.code); got != tt.want {
+				t.Errorf("HTTPStatusFromCode(%s) = %d, want %d", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebSocketCloseCodeFromCode(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want int
+	}{
+		{codes.OK, 1000},
+		{codes.Canceled, 4001},
+		{codes.InvalidArgument, 4003},
+		{codes.DeadlineExceeded, 4004},
+		{codes.ResourceExhausted, 4008},
+		{codes.Unavailable, 4014},
+		{codes.Unauthenticated, 4016},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			if got := WebSocketCloseCodeFromCode(tt.code); got != tt.want {
+				t.Errorf("WebSocketCloseCodeFromCode(%s) = %d, want %d", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCloseReason(t *testing.T) {
+	tests := []struct {
+		name string
+		st   *status.Status
+		want string
+	}{
+		{
+			name: "ok",
+			st:   status.New(codes.OK, "ignored"),
+			want: "",
+		},
+		{
+			name: "code only",
+			st:   status.New(codes.Unavailable, ""),
+			want: "UNAVAILABLE",
+		},
+		{
+			name: "code and message",
+			st:   status.New(codes.Unavailable, "connection refused"),
+			want: "UNAVAILABLE: connection refused",
+		},
+		{
+			name: "fits exactly",
+			st:   status.New(codes.Internal, strings.Repeat("a", maxCloseReason-len("INTERNAL: "))),
+			want: "INTERNAL: " + strings.Repeat("a", maxCloseReason-len("INTERNAL: ")),
+		},
+		{
+			name: "too long",
+			st:   status.New(codes.Internal, strings.Repeat("a", 200)),
+			want: "INTERNAL: " + strings.Repeat("a", maxCloseReason-len("INTERNAL: ")),
+		},
+		{
+			// The cut falls inside the last "é", which is dropped whole.
+			name: "multibyte",
+			st:   status.New(codes.Internal, strings.Repeat("a", maxCloseReason-len("INTERNAL: ")-1)+"é"),
+			want: "INTERNAL: " + strings.Repeat("a", maxCloseReason-len("INTERNAL: ")-1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := closeReason(tt.st)
+			if got != tt.want {
+				t.Errorf("closeReason() = %q, want %q", got, tt.want)
+			}
+			if len(got) > maxCloseReason {
+				t.Errorf("closeReason() is %d bytes, want at most %d", len(got), maxCloseReason)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("closeReason() = %q, want valid UTF-8", got)
+			}
+		})
+	}
+}
+
+func TestEncodeGrpcMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{msg: "", want: ""},
+		{msg: "connection refused", want: "connection refused"},
+		{msg: "100% done", want: "100%25 done"},
+		{msg: "line\nbreak\r", want: "line%0Abreak%0D"},
+		{msg: "tab\tdel\x7f", want: "tab%09del%7F"},
+		{msg: "café", want: "caf%C3%A9"},
+		{msg: "日本", want: "%E6%97%A5%E6%9C%AC"},
+		{msg: "bad\xffutf8", want: "bad%EF%BF%BDutf8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			if got := encodeGrpcMessage(tt.msg); got != tt.want {
+				t.Errorf("encodeGrpcMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineWriterEndEncodesMessage(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	lines := newLineWriter(recorder)
+	lines.end(status.Error(codes.Internal, "100% broken\nçà"))
+
+	if got := recorder.Header().Get("Grpc-Status"); got != "13" {
+		t.Errorf("Grpc-Status = %q, want 13", got)
+	}
+	if got, want := recorder.Header().Get("Grpc-Message"), "100%25 broken%0A%C3%A7%C3%A0"; got != want {
+		t.Errorf("Grpc-Message = %q, want %q", got, want)
+	}
+}
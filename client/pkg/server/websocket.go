@@ -1,13 +1,25 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/metrics"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/session"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/settings"
 	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 var upgrader = websocket.Upgrader{
@@ -18,17 +30,21 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-const (
-	writeWait  = 10 * time.Second
-	pongWait   = 30 * time.Second
-	pingPeriod = (pongWait * 9) / 10
-)
-
 type WSMessage struct {
 	StreamID       string `json:"stream_id"`
 	SequenceNumber int64  `json:"sequence_number"`
 	Timestamp      int64  `json:"timestamp"`
 	Message        string `json:"message"`
+
+	// Server stream controls; interval and jitter are Go durations ("250ms").
+	Count          int32  `json:"count,omitempty"`
+	Interval       string `json:"interval,omitempty"`
+	Jitter         string `json:"jitter,omitempty"`
+	UntilCancelled bool   `json:"until_cancelled,omitempty"`
+
+	// HalfClose ends the input of a client or bidirectional stream while
+	// keeping the socket open for the remaining responses and the status.
+	HalfClose bool `json:"half_close,omitempty"`
 }
 
 type WSResponse struct {
@@ -37,88 +53,221 @@ type WSResponse struct {
 	Timestamp      int64  `json:"timestamp"`
 	Response       string `json:"response"`
 	Success        bool   `json:"success"`
+
+	RequestEncoding  string `json:"request_encoding,omitempty"`
+	ResponseEncoding string `json:"response_encoding,omitempty"`
+
+	PreviousRPCAttempts int32 `json:"previous_rpc_attempts,omitempty"`
+}
+
+// WSError is sent instead of a WSResponse when a message is rejected or the
+// gRPC stream fails.
+type WSError struct {
+	Error statusBody `json:"error"`
+}
+
+func newWSError(err error) WSError {
+	return WSError{Error: newStatusBody(status.Convert(err))}
+}
+
+// sendStatus sends err as a WSError, keeping the session open.
+func sendStatus(s *session.Session, err error) {
+	log.Info().Uint64("session", s.ID()).Msg(err.Error())
+	s.Send(newWSError(err))
+}
+
+// closeWithStatus ends the session with the status of a finished stream: a
+// WSError when it failed, then a close frame whose code is derived from the
+// status code (see WebSocketCloseCodeFromCode) and whose reason is the status.
+func closeWithStatus(s *session.Session, err error) {
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	if err != nil {
+		sendStatus(s, err)
+	}
+
+	st := status.Convert(err)
+	s.Close(WebSocketCloseCodeFromCode(st.Code()), closeReason(st))
+}
+
+func invalidMessage(err error) error {
+	return status.Errorf(codes.InvalidArgument, "invalid message format: %v", err)
+}
+
+func newWSResponse(resp *pb.StreamResponse) WSResponse {
+	return WSResponse{
+		StreamID:            resp.StreamId,
+		SequenceNumber:      resp.SequenceNumber,
+		Timestamp:           resp.Timestamp,
+		Response:            resp.Response,
+		Success:             resp.Success,
+		RequestEncoding:     resp.RequestEncoding,
+		ResponseEncoding:    resp.ResponseEncoding,
+		PreviousRPCAttempts: resp.PreviousRpcAttempts,
+	}
 }
 
 type WebSocketHandler struct {
+	settings        settings.Settings
+	client          pb.ServerClient
 	streamingClient pb.StreamingServerClient
+	config          session.Config
+
+	mu       sync.Mutex
+	sessions map[uint64]*session.Session
+	closing  bool
+	active   sync.WaitGroup
 }
 
-func NewWebSocketHandler(client pb.StreamingServerClient) *WebSocketHandler {
+func NewWebSocketHandler(settings settings.Settings, client pb.ServerClient, streamingClient pb.StreamingServerClient, config session.Config) *WebSocketHandler {
 	return &WebSocketHandler{
-		streamingClient: client,
+		settings:        settings,
+		client:          client,
+		streamingClient: streamingClient,
+		config:          config,
+		sessions:        make(map[uint64]*session.Session),
 	}
 }
 
-func (h *WebSocketHandler) HandleBidirectional(w http.ResponseWriter, r *http.Request) {
+// CloseAll closes every open session, and sessions opened from now on, with
+// 1001 "going away" and waits for their handlers to return or ctx to be done.
+func (h *WebSocketHandler) CloseAll(ctx context.Context) {
+	h.mu.Lock()
+	h.closing = true
+	for _, s := range h.sessions {
+		s.Close(websocket.CloseGoingAway, "server shutting down")
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.active.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// HandleSessions lists the stats of every open session.
+func (h *WebSocketHandler) HandleSessions(w http.ResponseWriter, req *http.Request) {
+	h.mu.Lock()
+	stats := make([]session.Stats, 0, len(h.sessions))
+	for _, s := range h.sessions {
+		stats = append(stats, s.Stats())
+	}
+	h.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].ID < stats[j].ID
+	})
+
+	data, _ := json.Marshal(stats)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// open upgrades the request, negotiating one of subprotocols when the caller
+// offers it, and starts a session on endpoint. The returned function closes
+// the session normally, unless it was already closed, waits for the queued
+// messages to be written and logs the session stats.
+func (h *WebSocketHandler) open(w http.ResponseWriter, r *http.Request, endpoint string, subprotocols []string) (*session.Session, func(), error) {
+	upgrader := upgrader
+	upgrader.Subprotocols = subprotocols
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Error().Err(err).Msg("websocket upgrade failed")
+		return nil, nil, err
+	}
+
+	closed := metrics.WebSocketOpened(endpoint)
+	s := session.New(conn, endpoint, h.config)
+
+	h.mu.Lock()
+	h.sessions[s.ID()] = s
+	h.active.Add(1)
+	if h.closing {
+		s.Close(websocket.CloseGoingAway, "server shutting down")
+	}
+	h.mu.Unlock()
+
+	return s, func() {
+		s.Close(websocket.CloseNormalClosure, "")
+		<-s.Done()
+		s.Log()
+		closed()
+
+		h.mu.Lock()
+		delete(h.sessions, s.ID())
+		h.active.Done()
+		h.mu.Unlock()
+	}, nil
+}
+
+func (h *WebSocketHandler) HandleBidirectional(w http.ResponseWriter, r *http.Request) {
+	s, closeSession, err := h.open(w, r, "bidirectional", streamSubprotocols)
+	if err != nil {
 		return
 	}
-	defer conn.Close()
+	defer closeSession()
+	codec := newWSCodec(s.Subprotocol())
 
 	stream, err := h.streamingClient.BidirectionalStream(r.Context())
 	if err != nil {
 		log.Error().Err(err).Msg("failed to create bidirectional stream")
-		conn.WriteMessage(websocket.CloseMessage,
-			websocket.FormatCloseMessage(1011, "gRPC connection failed"))
+		closeWithStatus(s, err)
 		return
 	}
 
-	// Handle incoming responses from gRPC
+	// Forward the gRPC responses; once the stream ends the session is closed
+	// with its status, which also ends the read loop below.
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
 		for {
 			resp, err := stream.Recv()
 			if err != nil {
+				closeWithStatus(s, err)
 				return
 			}
 
-			wsResp := WSResponse{
-				StreamID:       resp.StreamId,
-				SequenceNumber: resp.SequenceNumber,
-				Timestamp:      resp.Timestamp,
-				Response:       resp.Response,
-				Success:        resp.Success,
-			}
-
-			data, _ := json.Marshal(wsResp)
-			conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			if err := codec.send(s, resp); err != nil {
 				return
 			}
 		}
 	}()
 
-	// Handle incoming messages from WebSocket
-	conn.SetReadDeadline(time.Now().Add(pongWait))
-	conn.SetPongHandler(func(string) error {
-		conn.SetReadDeadline(time.Now().Add(pongWait))
-		return nil
-	})
-
+	halfClosed := false
 	for {
-		_, message, err := conn.ReadMessage()
+		messageType, message, err := s.ReadMessage()
 		if err != nil {
 			break
 		}
 
-		var wsMsg WSMessage
-		if err := json.Unmarshal(message, &wsMsg); err != nil {
-			errResp := WSResponse{Success: false, Response: "invalid message format"}
-			data, _ := json.Marshal(errResp)
-			conn.WriteMessage(websocket.TextMessage, data)
+		if halfClosed {
+			sendStatus(s, status.Error(codes.FailedPrecondition, "stream is half-closed"))
 			continue
 		}
 
-		pbMsg := &pb.StreamMessage{
-			StreamId:       wsMsg.StreamID,
-			SequenceNumber: wsMsg.SequenceNumber,
-			Timestamp:      wsMsg.Timestamp,
-			Message:        wsMsg.Message,
+		pbMsg, halfClose, err := codec.decode(messageType, message)
+		if err != nil {
+			sendStatus(s, err)
+			continue
 		}
 
+		if halfClose {
+			// Keep reading for pongs until the responses are drained.
+			halfClosed = true
+			stream.CloseSend()
+			continue
+		}
+
+		// A failed Send is reported by Recv with the real status.
 		if err := stream.Send(pbMsg); err != nil {
 			break
 		}
@@ -129,39 +278,46 @@ func (h *WebSocketHandler) HandleBidirectional(w http.ResponseWriter, r *http.Re
 }
 
 func (h *WebSocketHandler) HandleServerStream(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	s, closeSession, err := h.open(w, r, "server", streamSubprotocols)
 	if err != nil {
-		log.Error().Err(err).Msg("websocket upgrade failed")
 		return
 	}
-	defer conn.Close()
+	defer closeSession()
+	codec := newWSCodec(s.Subprotocol())
 
 	// Read single message from WebSocket
-	_, message, err := conn.ReadMessage()
+	messageType, message, err := s.ReadMessage()
 	if err != nil {
 		return
 	}
 
-	var wsMsg WSMessage
-	if err := json.Unmarshal(message, &wsMsg); err != nil {
-		errResp := WSResponse{Success: false, Response: "invalid message format"}
-		data, _ := json.Marshal(errResp)
-		conn.WriteMessage(websocket.TextMessage, data)
+	pbMsg, halfClose, err := codec.decode(messageType, message)
+	if err != nil {
+		closeWithStatus(s, err)
 		return
 	}
-
-	pbMsg := &pb.StreamMessage{
-		StreamId:       wsMsg.StreamID,
-		SequenceNumber: wsMsg.SequenceNumber,
-		Timestamp:      wsMsg.Timestamp,
-		Message:        wsMsg.Message,
+	if halfClose {
+		closeWithStatus(s, status.Error(codes.InvalidArgument, "server streams start with a message"))
+		return
 	}
 
-	stream, err := h.streamingClient.ServerStream(r.Context(), pbMsg)
+	// Keep reading so pongs are handled and an until_cancelled stream is
+	// cancelled when the browser closes the socket.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := s.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	stream, err := h.streamingClient.ServerStream(ctx, pbMsg)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to create server stream")
-		conn.WriteMessage(websocket.CloseMessage,
-			websocket.FormatCloseMessage(1011, "gRPC connection failed"))
+		closeWithStatus(s, err)
 		return
 	}
 
@@ -169,68 +325,69 @@ func (h *WebSocketHandler) HandleServerStream(w http.ResponseWriter, r *http.Req
 	for {
 		resp, err := stream.Recv()
 		if err != nil {
-			break
+			closeWithStatus(s, err)
+			return
 		}
 
-		wsResp := WSResponse{
-			StreamID:       resp.StreamId,
-			SequenceNumber: resp.SequenceNumber,
-			Timestamp:      resp.Timestamp,
-			Response:       resp.Response,
-			Success:        resp.Success,
+		if err := codec.send(s, resp); err != nil {
+			return
 		}
+	}
+}
 
-		data, _ := json.Marshal(wsResp)
-		conn.SetWriteDeadline(time.Now().Add(writeWait))
-		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
-			return
+// setStreamDurations copies the optional interval and jitter of wsMsg onto
+// msg, leaving them unset so the server defaults apply when empty.
+func setStreamDurations(msg *pb.StreamMessage, wsMsg WSMessage) error {
+	if wsMsg.Interval != "" {
+		d, err := time.ParseDuration(wsMsg.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid interval: %w", err)
+		}
+		msg.Interval = durationpb.New(d)
+	}
+
+	if wsMsg.Jitter != "" {
+		d, err := time.ParseDuration(wsMsg.Jitter)
+		if err != nil {
+			return fmt.Errorf("invalid jitter: %w", err)
 		}
+		msg.Jitter = durationpb.New(d)
 	}
+
+	return nil
 }
 
 func (h *WebSocketHandler) HandleClientStream(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	s, closeSession, err := h.open(w, r, "client", streamSubprotocols)
 	if err != nil {
-		log.Error().Err(err).Msg("websocket upgrade failed")
 		return
 	}
-	defer conn.Close()
+	defer closeSession()
+	codec := newWSCodec(s.Subprotocol())
 
 	stream, err := h.streamingClient.ClientStream(r.Context())
 	if err != nil {
 		log.Error().Err(err).Msg("failed to create client stream")
-		conn.WriteMessage(websocket.CloseMessage,
-			websocket.FormatCloseMessage(1011, "gRPC connection failed"))
+		closeWithStatus(s, err)
 		return
 	}
 
-	// Read messages until client closes
-	conn.SetReadDeadline(time.Now().Add(pongWait))
-	conn.SetPongHandler(func(string) error {
-		conn.SetReadDeadline(time.Now().Add(pongWait))
-		return nil
-	})
-
+	// Read messages until the client half-closes or closes the socket
 	for {
-		_, message, err := conn.ReadMessage()
+		messageType, message, err := s.ReadMessage()
 		if err != nil {
 			// Client closed connection, close gRPC stream and get response
 			break
 		}
 
-		var wsMsg WSMessage
-		if err := json.Unmarshal(message, &wsMsg); err != nil {
-			errResp := WSResponse{Success: false, Response: "invalid message format"}
-			data, _ := json.Marshal(errResp)
-			conn.WriteMessage(websocket.TextMessage, data)
+		pbMsg, halfClose, err := codec.decode(messageType, message)
+		if err != nil {
+			sendStatus(s, err)
 			continue
 		}
 
-		pbMsg := &pb.StreamMessage{
-			StreamId:       wsMsg.StreamID,
-			SequenceNumber: wsMsg.SequenceNumber,
-			Timestamp:      wsMsg.Timestamp,
-			Message:        wsMsg.Message,
+		if halfClose {
+			break
 		}
 
 		if err := stream.Send(pbMsg); err != nil {
@@ -241,19 +398,9 @@ func (h *WebSocketHandler) HandleClientStream(w http.ResponseWriter, r *http.Req
 	// Close send and receive summary
 	resp, err := stream.CloseAndRecv()
 	if err != nil {
-		log.Error().Err(err).Msg("failed to close client stream")
+		closeWithStatus(s, err)
 		return
 	}
 
-	wsResp := WSResponse{
-		StreamID:       resp.StreamId,
-		SequenceNumber: resp.SequenceNumber,
-		Timestamp:      resp.Timestamp,
-		Response:       resp.Response,
-		Success:        resp.Success,
-	}
-
-	data, _ := json.Marshal(wsResp)
-	conn.SetWriteDeadline(time.Now().Add(writeWait))
-	conn.WriteMessage(websocket.TextMessage, data)
+	codec.send(s, resp)
 }
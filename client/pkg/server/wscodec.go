@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/session"
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Subprotocols the /ws/stream routes negotiate with Sec-WebSocket-Protocol.
+// Without one, frames are WSMessage and WSResponse JSON.
+const (
+	// ProtoSubprotocol frames are binary serialized pb.StreamMessage and
+	// pb.StreamResponse.
+	ProtoSubprotocol = "echo.proto.v1"
+	// ProtoJSONSubprotocol frames are pb.StreamMessage and pb.StreamResponse
+	// in protojson, with its camelCase field names, string int64s and
+	// "1.5s" durations.
+	ProtoJSONSubprotocol = "echo.protojson.v1"
+)
+
+var streamSubprotocols = []string{ProtoSubprotocol, ProtoJSONSubprotocol}
+
+// wsCodec converts the frames of a /ws/stream session. Error frames are
+// WSError JSON text frames whatever the codec.
+type wsCodec interface {
+	// decode returns the message of a frame, or halfClose when the frame
+	// half-closes the stream.
+	decode(messageType int, data []byte) (msg *pb.StreamMessage, halfClose bool, err error)
+	send(s *session.Session, resp *pb.StreamResponse) error
+}
+
+func newWSCodec(subprotocol string) wsCodec {
+	switch subprotocol {
+	case ProtoSubprotocol:
+		return protoCodec{}
+	case ProtoJSONSubprotocol:
+		return protoJSONCodec{}
+	default:
+		return jsonCodec{}
+	}
+}
+
+// jsonCodec speaks WSMessage and WSResponse.
+type jsonCodec struct{}
+
+func (jsonCodec) decode(_ int, data []byte) (*pb.StreamMessage, bool, error) {
+	var wsMsg WSMessage
+	if err := json.Unmarshal(data, &wsMsg); err != nil {
+		return nil, false, invalidMessage(err)
+	}
+	if wsMsg.HalfClose {
+		return nil, true, nil
+	}
+
+	msg := &pb.StreamMessage{
+		StreamId:       wsMsg.StreamID,
+		SequenceNumber: wsMsg.SequenceNumber,
+		Timestamp:      wsMsg.Timestamp,
+		Message:        wsMsg.Message,
+		Count:          wsMsg.Count,
+		UntilCancelled: wsMsg.UntilCancelled,
+	}
+	if err := setStreamDurations(msg, wsMsg); err != nil {
+		return nil, false, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return msg, false, nil
+}
+
+func (jsonCodec) send(s *session.Session, resp *pb.StreamResponse) error {
+	return s.Send(newWSResponse(resp))
+}
+
+// protoControl is the text frame half-closing a stream on the protobuf
+// subprotocols: {"halfClose":true}.
+type protoControl struct {
+	HalfClose bool `json:"halfClose"`
+}
+
+// protoCodec speaks binary protobuf; text frames only carry protoControl.
+type protoCodec struct{}
+
+func (protoCodec) decode(messageType int, data []byte) (*pb.StreamMessage, bool, error) {
+	if messageType == websocket.TextMessage {
+		var control protoControl
+		if err := json.Unmarshal(data, &control); err != nil || !control.HalfClose {
+			return nil, false, status.Errorf(codes.InvalidArgument, "%s takes binary messages, text frames only half-close", ProtoSubprotocol)
+		}
+		return nil, true, nil
+	}
+
+	msg := &pb.StreamMessage{}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, false, invalidMessage(err)
+	}
+
+	return msg, false, nil
+}
+
+func (protoCodec) send(s *session.Session, resp *pb.StreamResponse) error {
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	return s.SendMessage(websocket.BinaryMessage, data)
+}
+
+// protoJSONCodec speaks protojson text frames.
+type protoJSONCodec struct{}
+
+func (protoJSONCodec) decode(messageType int, data []byte) (*pb.StreamMessage, bool, error) {
+	if messageType != websocket.TextMessage {
+		return nil, false, status.Errorf(codes.InvalidArgument, "%s takes text messages", ProtoJSONSubprotocol)
+	}
+
+	var control protoControl
+	if err := json.Unmarshal(data, &control); err == nil && control.HalfClose {
+		return nil, true, nil
+	}
+
+	msg := &pb.StreamMessage{}
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, false, invalidMessage(err)
+	}
+
+	return msg, false, nil
+}
+
+func (protoJSONCodec) send(s *session.Session, resp *pb.StreamResponse) error {
+	data, err := protojson.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	return s.SendMessage(websocket.TextMessage, data)
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type decodeTest struct {
+	name          string
+	messageType   int
+	data          []byte
+	want          *pb.StreamMessage
+	wantHalfClose bool
+	wantErr       bool
+}
+
+func runDecodeTests(t *testing.T, codec wsCodec, tests []decodeTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, halfClose, err := codec.decode(tt.messageType, tt.data)
+			if tt.wantErr {
+				if status.Code(err) != codes.InvalidArgument {
+					t.Fatalf("decode() error = %v, want InvalidArgument", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decode() failed: %v", err)
+			}
+			if halfClose != tt.wantHalfClose {
+				t.Errorf("decode() halfClose = %v, want %v", halfClose, tt.wantHalfClose)
+			}
+			if !proto.Equal(got, tt.want) {
+				t.Errorf("decode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWSCodec(t *testing.T) {
+	tests := []struct {
+		subprotocol string
+		want        wsCodec
+	}{
+		{"", jsonCodec{}},
+		{"unknown", jsonCodec{}},
+		{ProtoSubprotocol, protoCodec{}},
+		{ProtoJSONSubprotocol, protoJSONCodec{}},
+	}
+
+	for _, tt := range tests {
+		if got := newWSCodec(tt.subprotocol); got != tt.want {
+			t.Errorf("newWSCodec(%q) = %T, want %T", tt.subprotocol, got, tt.want)
+		}
+	}
+}
+
+func TestJSONCodecDecode(t *testing.T) {
+	runDecodeTests(t, jsonCodec{}, []decodeTest{
+		{
+			name:        "message",
+			messageType: websocket.TextMessage,
+			data:        []byte(`{"stream_id":"1","sequence_number":2,"timestamp":3,"message":"hello"}`),
+			want:        &pb.StreamMessage{StreamId: "1", SequenceNumber: 2, Timestamp: 3, Message: "hello"},
+		},
+		{
+			name:        "stream controls",
+			messageType: websocket.TextMessage,
+			data:        []byte(`{"stream_id":"1","count":3,"interval":"250ms","jitter":"10ms","until_cancelled":true}`),
+			want: &pb.StreamMessage{
+				StreamId:       "1",
+				Count:          3,
+				Interval:       durationpb.New(250 * time.Millisecond),
+				Jitter:         durationpb.New(10 * time.Millisecond),
+				UntilCancelled: true,
+			},
+		},
+		{
+			name:        "binary frame",
+			messageType: websocket.BinaryMessage,
+			data:        []byte(`{"stream_id":"1"}`),
+			want:        &pb.StreamMessage{StreamId: "1"},
+		},
+		{
+			name:          "half close",
+			messageType:   websocket.TextMessage,
+			data:          []byte(`{"half_close":true}`),
+			wantHalfClose: true,
+		},
+		{
+			name:        "invalid json",
+			messageType: websocket.TextMessage,
+			data:        []byte(`{`),
+			wantErr:     true,
+		},
+		{
+			name:        "invalid interval",
+			messageType: websocket.TextMessage,
+			data:        []byte(`{"interval":"soon"}`),
+			wantErr:     true,
+		},
+		{
+			name:        "invalid jitter",
+			messageType: websocket.TextMessage,
+			data:        []byte(`{"jitter":"10"}`),
+			wantErr:     true,
+		},
+	})
+}
+
+func TestProtoCodecDecode(t *testing.T) {
+	msg := &pb.StreamMessage{StreamId: "1", SequenceNumber: 2, Message: "hello", Interval: durationpb.New(time.Second)}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runDecodeTests(t, protoCodec{}, []decodeTest{
+		{
+			name:        "message",
+			messageType: websocket.BinaryMessage,
+			data:        data,
+			want:        msg,
+		},
+		{
+			name:        "empty message",
+			messageType: websocket.BinaryMessage,
+			data:        nil,
+			want:        &pb.StreamMessage{},
+		},
+		{
+			name:          "half close",
+			messageType:   websocket.TextMessage,
+			data:          []byte(`{"halfClose":true}`),
+			wantHalfClose: true,
+		},
+		{
+			name:        "text message",
+			messageType: websocket.TextMessage,
+			data:        []byte(`{"streamId":"1"}`),
+			wantErr:     true,
+		},
+		{
+			name:        "text half close false",
+			messageType: websocket.TextMessage,
+			data:        []byte(`{"halfClose":false}`),
+			wantErr:     true,
+		},
+		{
+			name:        "invalid protobuf",
+			messageType: websocket.BinaryMessage,
+			data:        []byte{0xff, 0xff, 0xff},
+			wantErr:     true,
+		},
+	})
+}
+
+func TestProtoJSONCodecDecode(t *testing.T) {
+	msg := &pb.StreamMessage{StreamId: "1", SequenceNumber: 2, Message: "hello", Interval: durationpb.New(1500 * time.Millisecond)}
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runDecodeTests(t, protoJSONCodec{}, []decodeTest{
+		{
+			name:        "message",
+			messageType: websocket.TextMessage,
+			data:        data,
+			want:        msg,
+		},
+		{
+			name:        "camel case and string int64",
+			messageType: websocket.TextMessage,
+			data:        []byte(`{"streamId":"1","sequenceNumber":"2","untilCancelled":true,"jitter":"0.5s"}`),
+			want:        &pb.StreamMessage{StreamId: "1", SequenceNumber: 2, UntilCancelled: true, Jitter: durationpb.New(500 * time.Millisecond)},
+		},
+		{
+			name:          "half close",
+			messageType:   websocket.TextMessage,
+			data:          []byte(`{"halfClose":true}`),
+			wantHalfClose: true,
+		},
+		{
+			name:        "binary frame",
+			messageType: websocket.BinaryMessage,
+			data:        data,
+			wantErr:     true,
+		},
+		{
+			name:        "unknown field",
+			messageType: websocket.TextMessage,
+			data:        []byte(`{"stream_idd":"1"}`),
+			wantErr:     true,
+		},
+		{
+			name:        "invalid json",
+			messageType: websocket.TextMessage,
+			data:        []byte(`{`),
+			wantErr:     true,
+		},
+	})
+}
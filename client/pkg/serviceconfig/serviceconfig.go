@@ -0,0 +1,99 @@
+// Package serviceconfig builds the default gRPC service config of the client
+// from an optional JSON file and the load balancing policy setting.
+package serviceconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/lb"
+	"google.golang.org/grpc/balancer"
+)
+
+// outlierDetection is the balancer only registered by builds with the xds
+// tag, see xds.go.
+const outlierDetection = "outlier_detection_experimental"
+
+// Load returns the service config in path, or an empty one when path is
+// empty, with loadBalancingConfig set to policy unless the file chooses a
+// balancer itself. grpc validates the result when dialing.
+func Load(path, policy string) (string, error) {
+	config := make(map[string]json.RawMessage)
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read service config: %w", err)
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return "", fmt.Errorf("invalid service config %s: %w", path, err)
+		}
+		warnUnsupported(config)
+		if err := checkOutlierDetection(config); err != nil {
+			return "", err
+		}
+	}
+
+	_, hasConfig := config["loadBalancingConfig"]
+	_, hasPolicy := config["loadBalancingPolicy"]
+	if !hasConfig && !hasPolicy {
+		lbConfig, err := lb.LoadBalancingConfig(policy)
+		if err != nil {
+			return "", err
+		}
+		config["loadBalancingConfig"] = lbConfig
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// checkOutlierDetection fails when a build without outlier detection gets a
+// config that asks for it and lists no other registered balancer to fall back
+// to. grpc would reject that config when dialing, with no hint at the build
+// tag.
+func checkOutlierDetection(config map[string]json.RawMessage) error {
+	var policies []map[string]json.RawMessage
+	if err := json.Unmarshal(config["loadBalancingConfig"], &policies); err != nil {
+		return nil
+	}
+
+	missing := false
+	for _, policy := range policies {
+		for name := range policy {
+			if balancer.Get(name) != nil {
+				return nil
+			}
+			if name == outlierDetection {
+				missing = true
+			}
+		}
+	}
+	if missing {
+		return fmt.Errorf("%s is only available in clients built with -tags xds", outlierDetection)
+	}
+	return nil
+}
+
+// warnUnsupported logs the method configs with a hedgingPolicy: grpc-go
+// parses service configs leniently and silently ignores hedging, which it
+// does not implement.
+func warnUnsupported(config map[string]json.RawMessage) {
+	var methodConfigs []map[string]json.RawMessage
+	if err := json.Unmarshal(config["methodConfig"], &methodConfigs); err != nil {
+		return
+	}
+
+	for _, methodConfig := range methodConfigs {
+		if _, ok := methodConfig["hedgingPolicy"]; ok {
+			log.Warn().
+				RawJSON("name", methodConfig["name"]).
+				Msg("hedgingPolicy is not implemented by grpc-go and is ignored")
+		}
+	}
+}
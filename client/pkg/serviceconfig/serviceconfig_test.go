@@ -0,0 +1,56 @@
+package serviceconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/balancer"
+)
+
+func TestLoadOutlierDetection(t *testing.T) {
+	if balancer.Get(outlierDetection) != nil {
+		t.Skip("built with the xds tag")
+	}
+
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{
+			name:    "outlier detection only",
+			config:  `{"loadBalancingConfig": [{"outlier_detection_experimental": {}}]}`,
+			wantErr: true,
+		},
+		{
+			name:   "falls back to round_robin",
+			config: `{"loadBalancingConfig": [{"outlier_detection_experimental": {}}, {"round_robin": {}}]}`,
+		},
+		{
+			name:   "round_robin",
+			config: `{"loadBalancingConfig": [{"round_robin": {}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "service-config.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Load(path, "pick_first")
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "-tags xds") {
+					t.Fatalf("Load() error = %v, want one naming the xds tag", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+		})
+	}
+}
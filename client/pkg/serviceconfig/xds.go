@@ -0,0 +1,12 @@
+//go:build xds
+
+package serviceconfig
+
+import (
+	// Registers the outlier_detection_experimental balancer. grpc-go keeps
+	// it in an internal package of its xDS support, so the whole xDS stack
+	// (resolvers, credentials and their dependencies) comes with it and
+	// roughly doubles the size of the client binary. It is only linked into
+	// builds with the xds tag.
+	_ "google.golang.org/grpc/xds"
+)
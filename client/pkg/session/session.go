@@ -0,0 +1,380 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/metrics"
+)
+
+// OverflowPolicy decides what Send does when the outbound queue is full.
+type OverflowPolicy string
+
+const (
+	// Block waits for room in the queue, pushing back on the gRPC stream.
+	Block OverflowPolicy = "block"
+	// DropOldest discards the oldest queued message to make room.
+	DropOldest OverflowPolicy = "drop-oldest"
+	// Disconnect closes the session with 1008 policy violation.
+	Disconnect OverflowPolicy = "disconnect"
+)
+
+// ParseOverflowPolicy returns the policy named s.
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(s); policy {
+	case Block, DropOldest, Disconnect:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown overflow policy %q, must be block, drop-oldest or disconnect", s)
+	}
+}
+
+// ErrClosed is returned by Send once the session is closing.
+var ErrClosed = errors.New("session closed")
+
+// Config is shared by every session of a handler.
+type Config struct {
+	QueueSize    int
+	Overflow     OverflowPolicy
+	PingInterval time.Duration
+	PongWait     time.Duration
+	WriteWait    time.Duration
+}
+
+// Validate checks the settings a session would otherwise panic or misbehave
+// on. PongWait must be longer than PingInterval so a healthy peer answers a
+// ping before its read deadline.
+func (c Config) Validate() error {
+	if c.QueueSize < 0 {
+		return fmt.Errorf("queue size must not be negative, got %d", c.QueueSize)
+	}
+
+	for name, d := range map[string]time.Duration{
+		"ping interval": c.PingInterval,
+		"pong wait":     c.PongWait,
+		"write wait":    c.WriteWait,
+	} {
+		if d <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", name, d)
+		}
+	}
+
+	if c.PongWait <= c.PingInterval {
+		return fmt.Errorf("pong wait (%s) must be longer than ping interval (%s)", c.PongWait, c.PingInterval)
+	}
+
+	if _, err := ParseOverflowPolicy(string(c.Overflow)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Stats describes a session, it is logged when the session ends.
+type Stats struct {
+	ID               uint64    `json:"id"`
+	Endpoint         string    `json:"endpoint"`
+	RemoteAddr       string    `json:"remote_addr"`
+	Subprotocol      string    `json:"subprotocol,omitempty"`
+	StartedAt        time.Time `json:"started_at"`
+	MessagesSent     int64     `json:"messages_sent"`
+	MessagesReceived int64     `json:"messages_received"`
+	BytesSent        int64     `json:"bytes_sent"`
+	BytesReceived    int64     `json:"bytes_received"`
+	MessagesDropped  int64     `json:"messages_dropped"`
+	QueueLength      int       `json:"queue_length"`
+	QueueHighWater   int64     `json:"queue_high_water"`
+	PingsSent        int64     `json:"pings_sent"`
+	PongsReceived    int64     `json:"pongs_received"`
+	LastPongRTT      string    `json:"last_pong_rtt,omitempty"`
+}
+
+type message struct {
+	messageType int
+	data        []byte
+}
+
+type closeFrame struct {
+	code   int
+	reason string
+}
+
+var lastID atomic.Uint64
+
+// Session owns a WebSocket connection. Every write goes through a single
+// writer goroutine fed by a bounded queue, which also pings the peer; reads
+// are made by the one goroutine calling ReadMessage.
+type Session struct {
+	conn     *websocket.Conn
+	config   Config
+	id       uint64
+	endpoint string
+	started  time.Time
+
+	queue     chan message
+	closeOnce sync.Once
+	closing   chan closeFrame
+	done      chan struct{}
+
+	sent, received         atomic.Int64
+	bytesSent, bytesRecv   atomic.Int64
+	dropped, highWater     atomic.Int64
+	pingsSent, pongsRecv   atomic.Int64
+	lastPingAt, lastPongNs atomic.Int64
+}
+
+// New starts the writer of a session on conn, opened on endpoint.
+func New(conn *websocket.Conn, endpoint string, config Config) *Session {
+	s := &Session{
+		conn:     conn,
+		config:   config,
+		id:       lastID.Add(1),
+		endpoint: endpoint,
+		started:  time.Now(),
+		queue:    make(chan message, config.QueueSize),
+		closing:  make(chan closeFrame, 1),
+		done:     make(chan struct{}),
+	}
+
+	conn.SetReadDeadline(time.Now().Add(config.PongWait))
+	conn.SetPongHandler(func(string) error {
+		s.pongsRecv.Add(1)
+		if at := s.lastPingAt.Load(); at != 0 {
+			s.lastPongNs.Store(time.Now().UnixNano() - at)
+		}
+		return conn.SetReadDeadline(time.Now().Add(config.PongWait))
+	})
+
+	go s.write()
+	return s
+}
+
+// ID identifies the session in logs and stats.
+func (s *Session) ID() uint64 {
+	return s.id
+}
+
+// Done is closed once the writer stopped and the connection is closed.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Subprotocol is the protocol negotiated with Sec-WebSocket-Protocol, empty
+// when none was.
+func (s *Session) Subprotocol() string {
+	return s.conn.Subprotocol()
+}
+
+// ReadMessage reads the next data message and its type, extending the read
+// deadline on every message and pong.
+func (s *Session) ReadMessage() (int, []byte, error) {
+	messageType, data, err := s.conn.ReadMessage()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	s.conn.SetReadDeadline(time.Now().Add(s.config.PongWait))
+	s.received.Add(1)
+	s.bytesRecv.Add(int64(len(data)))
+	return messageType, data, nil
+}
+
+// Send queues v as a JSON text message.
+func (s *Session) Send(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.SendMessage(websocket.TextMessage, data)
+}
+
+// SendMessage queues data as a message of messageType, text or binary,
+// applying the overflow policy when the queue is full.
+func (s *Session) SendMessage(messageType int, data []byte) error {
+	msg := message{messageType: messageType, data: data}
+
+	select {
+	case <-s.done:
+		return ErrClosed
+	case s.queue <- msg:
+		s.observeQueue()
+		return nil
+	default:
+	}
+
+	metrics.WebSocketQueueOverflow(s.endpoint, string(s.config.Overflow))
+
+	switch s.config.Overflow {
+	case DropOldest:
+		// Queue first and drop only when there is still no room, a single
+		// select would pick either at random once a slot is free.
+		for {
+			select {
+			case <-s.done:
+				return ErrClosed
+			case s.queue <- msg:
+				s.observeQueue()
+				return nil
+			default:
+			}
+
+			select {
+			case <-s.queue:
+				s.dropped.Add(1)
+			default:
+				// Nothing to drop, the queue is unbuffered or the
+				// writer just emptied it.
+				select {
+				case <-s.done:
+					return ErrClosed
+				case s.queue <- msg:
+					s.observeQueue()
+					return nil
+				}
+			}
+		}
+	case Disconnect:
+		log.Warn().
+			Uint64("session", s.id).
+			Str("endpoint", s.endpoint).
+			Int("queue_size", s.config.QueueSize).
+			Msg("websocket: outbound queue full, disconnecting")
+		s.Close(websocket.ClosePolicyViolation, "outbound queue full")
+		return ErrClosed
+	default:
+		select {
+		case <-s.done:
+			return ErrClosed
+		case s.queue <- msg:
+			s.observeQueue()
+			return nil
+		}
+	}
+}
+
+func (s *Session) observeQueue() {
+	length := int64(len(s.queue))
+	for {
+		high := s.highWater.Load()
+		if length <= high || s.highWater.CompareAndSwap(high, length) {
+			return
+		}
+	}
+}
+
+// Close sends a close frame with code and reason once the queued messages
+// are written, then closes the connection. Only the first call has effect.
+func (s *Session) Close(code int, reason string) {
+	s.closeOnce.Do(func() {
+		s.closing <- closeFrame{code: code, reason: reason}
+	})
+}
+
+// write is the only goroutine writing to the connection.
+func (s *Session) write() {
+	ticker := time.NewTicker(s.config.PingInterval)
+	defer func() {
+		ticker.Stop()
+		s.conn.Close()
+		close(s.done)
+	}()
+
+	for {
+		select {
+		case msg := <-s.queue:
+			if err := s.writeMessage(msg); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			s.lastPingAt.Store(time.Now().UnixNano())
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.config.WriteWait)); err != nil {
+				return
+			}
+			s.pingsSent.Add(1)
+
+		case frame := <-s.closing:
+			// Flush what is already queued so the peer gets every message
+			// before the close frame.
+		flush:
+			for {
+				select {
+				case msg := <-s.queue:
+					if err := s.writeMessage(msg); err != nil {
+						return
+					}
+				default:
+					break flush
+				}
+			}
+
+			s.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(frame.code, frame.reason),
+				time.Now().Add(s.config.WriteWait))
+			return
+		}
+	}
+}
+
+func (s *Session) writeMessage(msg message) error {
+	s.conn.SetWriteDeadline(time.Now().Add(s.config.WriteWait))
+	if err := s.conn.WriteMessage(msg.messageType, msg.data); err != nil {
+		return err
+	}
+
+	s.sent.Add(1)
+	s.bytesSent.Add(int64(len(msg.data)))
+	return nil
+}
+
+// Stats returns a snapshot of the session counters.
+func (s *Session) Stats() Stats {
+	stats := Stats{
+		ID:               s.id,
+		Endpoint:         s.endpoint,
+		Subprotocol:      s.conn.Subprotocol(),
+		RemoteAddr:       s.conn.RemoteAddr().String(),
+		StartedAt:        s.started,
+		MessagesSent:     s.sent.Load(),
+		MessagesReceived: s.received.Load(),
+		BytesSent:        s.bytesSent.Load(),
+		BytesReceived:    s.bytesRecv.Load(),
+		MessagesDropped:  s.dropped.Load(),
+		QueueLength:      len(s.queue),
+		QueueHighWater:   s.highWater.Load(),
+		PingsSent:        s.pingsSent.Load(),
+		PongsReceived:    s.pongsRecv.Load(),
+	}
+	if rtt := s.lastPongNs.Load(); rtt != 0 {
+		stats.LastPongRTT = time.Duration(rtt).String()
+	}
+
+	return stats
+}
+
+// Log writes the final stats of the session.
+func (s *Session) Log() {
+	stats := s.Stats()
+	log.Info().
+		Uint64("session", stats.ID).
+		Str("endpoint", stats.Endpoint).
+		Str("subprotocol", stats.Subprotocol).
+		Str("remote_addr", stats.RemoteAddr).
+		Dur("duration", time.Since(stats.StartedAt)).
+		Int64("messages_sent", stats.MessagesSent).
+		Int64("messages_received", stats.MessagesReceived).
+		Int64("bytes_sent", stats.BytesSent).
+		Int64("bytes_received", stats.BytesReceived).
+		Int64("messages_dropped", stats.MessagesDropped).
+		Int64("queue_high_water", stats.QueueHighWater).
+		Int64("pings_sent", stats.PingsSent).
+		Int64("pongs_received", stats.PongsReceived).
+		Msg("websocket: session ended")
+}
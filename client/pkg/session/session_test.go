@@ -0,0 +1,265 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestParseOverflowPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    OverflowPolicy
+		wantErr bool
+	}{
+		{value: "block", want: Block},
+		{value: "drop-oldest", want: DropOldest},
+		{value: "disconnect", want: Disconnect},
+		{value: "", wantErr: true},
+		{value: "Block", wantErr: true},
+		{value: "drop_oldest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseOverflowPolicy(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseOverflowPolicy(%q) = %q, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseOverflowPolicy(%q) failed: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseOverflowPolicy(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{
+		QueueSize:    64,
+		Overflow:     Block,
+		PingInterval: 27 * time.Second,
+		PongWait:     30 * time.Second,
+		WriteWait:    10 * time.Second,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{name: "defaults", modify: func(*Config) {}},
+		{name: "zero queue", modify: func(c *Config) { c.QueueSize = 0 }},
+		{name: "negative queue", modify: func(c *Config) { c.QueueSize = -1 }, wantErr: "queue size"},
+		{name: "zero ping interval", modify: func(c *Config) { c.PingInterval = 0 }, wantErr: "ping interval"},
+		{name: "negative ping interval", modify: func(c *Config) { c.PingInterval = -time.Second }, wantErr: "ping interval"},
+		{name: "zero pong wait", modify: func(c *Config) { c.PongWait = 0 }, wantErr: "pong wait"},
+		{name: "zero write wait", modify: func(c *Config) { c.WriteWait = 0 }, wantErr: "write wait"},
+		{name: "pong wait equal to ping interval", modify: func(c *Config) { c.PongWait = c.PingInterval }, wantErr: "longer than ping interval"},
+		{name: "pong wait shorter than ping interval", modify: func(c *Config) { c.PongWait = time.Second }, wantErr: "longer than ping interval"},
+		{name: "unknown policy", modify: func(c *Config) { c.Overflow = "drop-newest" }, wantErr: "overflow policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.modify(&config)
+
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// newQueueSession returns a session without a connection or writer, so its
+// queue only drains when the test reads it.
+func newQueueSession(policy OverflowPolicy, queueSize int) *Session {
+	return &Session{
+		config:   Config{QueueSize: queueSize, Overflow: policy},
+		endpoint: "test",
+		queue:    make(chan message, queueSize),
+		closing:  make(chan closeFrame, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+func fill(t *testing.T, s *Session, data ...string) {
+	t.Helper()
+
+	for _, d := range data {
+		if err := s.SendMessage(websocket.TextMessage, []byte(d)); err != nil {
+			t.Fatalf("SendMessage(%q) failed: %v", d, err)
+		}
+	}
+}
+
+func queued(s *Session) []string {
+	var data []string
+	for len(s.queue) > 0 {
+		data = append(data, string((<-s.queue).data))
+	}
+	return data
+}
+
+func TestSendMessageBlock(t *testing.T) {
+	s := newQueueSession(Block, 2)
+	fill(t, s, "a", "b")
+
+	sent := make(chan error, 1)
+	go func() {
+		sent <- s.SendMessage(websocket.TextMessage, []byte("c"))
+	}()
+
+	select {
+	case err := <-sent:
+		t.Fatalf("SendMessage() on a full queue returned %v, want it to block", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if got := string((<-s.queue).data); got != "a" {
+		t.Fatalf("first queued message = %q, want a", got)
+	}
+	if err := <-sent; err != nil {
+		t.Fatalf("SendMessage() failed once the queue had room: %v", err)
+	}
+
+	if got := queued(s); strings.Join(got, ",") != "b,c" {
+		t.Errorf("queue = %v, want [b c]", got)
+	}
+	if got := s.highWater.Load(); got != 2 {
+		t.Errorf("high water = %d, want 2", got)
+	}
+}
+
+func TestSendMessageBlockUnblocksOnClose(t *testing.T) {
+	s := newQueueSession(Block, 1)
+	fill(t, s, "a")
+
+	sent := make(chan error, 1)
+	go func() {
+		sent <- s.SendMessage(websocket.TextMessage, []byte("b"))
+	}()
+
+	close(s.done)
+	if err := <-sent; !errors.Is(err, ErrClosed) {
+		t.Fatalf("SendMessage() = %v, want ErrClosed", err)
+	}
+}
+
+func TestSendMessageDropOldest(t *testing.T) {
+	s := newQueueSession(DropOldest, 2)
+	fill(t, s, "a", "b", "c", "d")
+
+	if got := queued(s); strings.Join(got, ",") != "c,d" {
+		t.Errorf("queue = %v, want [c d]", got)
+	}
+	if got := s.dropped.Load(); got != 2 {
+		t.Errorf("dropped = %d, want 2", got)
+	}
+}
+
+func TestSendMessageDisconnect(t *testing.T) {
+	s := newQueueSession(Disconnect, 1)
+	fill(t, s, "a")
+
+	if err := s.SendMessage(websocket.TextMessage, []byte("b")); !errors.Is(err, ErrClosed) {
+		t.Fatalf("SendMessage() = %v, want ErrClosed", err)
+	}
+
+	select {
+	case frame := <-s.closing:
+		if frame.code != websocket.ClosePolicyViolation {
+			t.Errorf("close code = %d, want %d", frame.code, websocket.ClosePolicyViolation)
+		}
+	default:
+		t.Fatal("session was not closed")
+	}
+
+	if got := queued(s); strings.Join(got, ",") != "a" {
+		t.Errorf("queue = %v, want [a]", got)
+	}
+}
+
+func TestSendMessageAfterDone(t *testing.T) {
+	for _, policy := range []OverflowPolicy{Block, DropOldest, Disconnect} {
+		t.Run(string(policy), func(t *testing.T) {
+			s := newQueueSession(policy, 1)
+			fill(t, s, "a")
+			close(s.done)
+
+			if err := s.SendMessage(websocket.TextMessage, []byte("b")); !errors.Is(err, ErrClosed) {
+				t.Fatalf("SendMessage() = %v, want ErrClosed", err)
+			}
+		})
+	}
+}
+
+// TestSessionWriter runs a session over a real connection: messages arrive
+// in order with their type, followed by the close frame.
+func TestSessionWriter(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+
+		s := New(conn, "test", Config{
+			QueueSize:    4,
+			Overflow:     Block,
+			PingInterval: time.Minute,
+			PongWait:     2 * time.Minute,
+			WriteWait:    time.Second,
+		})
+		s.Send(map[string]string{"message": "one"})
+		s.SendMessage(websocket.BinaryMessage, []byte{1, 2})
+		s.Close(4014, "UNAVAILABLE")
+		<-s.Done()
+
+		if got := s.Stats().MessagesSent; got != 2 {
+			t.Errorf("messages sent = %d, want 2", got)
+		}
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	messageType, data, err := conn.ReadMessage()
+	if err != nil || messageType != websocket.TextMessage || string(data) != `{"message":"one"}` {
+		t.Fatalf("first message = %d %q %v, want the JSON text message", messageType, data, err)
+	}
+
+	messageType, data, err = conn.ReadMessage()
+	if err != nil || messageType != websocket.BinaryMessage || string(data) != "\x01\x02" {
+		t.Fatalf("second message = %d %q %v, want the binary message", messageType, data, err)
+	}
+
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != 4014 || closeErr.Text != "UNAVAILABLE" {
+		t.Fatalf("read after the messages = %v, want close 4014 UNAVAILABLE", err)
+	}
+}
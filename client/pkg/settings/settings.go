@@ -11,9 +11,48 @@ type Settings struct {
 	GRPCKeepalive        bool          `envconfig:"GRPC_CLIENT_KEEPALIVE" default:"false"`
 	GRPCKeepaliveTime    time.Duration `envconfig:"GRPC_CLIENT_KEEPALIVE_TIME" default:"10s"`
 	GRPCKeepaliveTimeout time.Duration `envconfig:"GRPC_CLIENT_KEEPALIVE_TIMEOUT" default:"20s"`
+	GRPCCompressor       string        `envconfig:"GRPC_CLIENT_COMPRESSOR"`
+	GRPCDefaultTimeout   time.Duration `envconfig:"GRPC_CLIENT_DEFAULT_TIMEOUT" default:"30s"`
 	GRPCServerHost       string        `envconfig:"GRPC_SERVER_HOST" default:"server"`
 	GRPCServerPort       string        `envconfig:"GRPC_SERVER_PORT" default:"8080"`
-	GRPCServerTLS        bool          `envconfig:"GRPC_SERVER_TLS" default:false`
+	GRPCServerTLS        bool          `envconfig:"GRPC_SERVER_TLS" default:"false"`
+
+	// GRPCServerTarget overrides GRPCServerHost and GRPCServerPort with a
+	// gRPC target such as dns:///server:8081 or static:///a:8081,b:8081.
+	GRPCServerTarget string `envconfig:"GRPC_SERVER_TARGET"`
+	GRPCLBPolicy     string `envconfig:"GRPC_CLIENT_LB_POLICY" default:"pick_first"`
+	// GRPCServiceConfigFile is a JSON gRPC service config (retries, timeouts,
+	// waitForReady, load balancing) applied to every call.
+	GRPCServiceConfigFile string `envconfig:"GRPC_CLIENT_SERVICE_CONFIG_FILE"`
+	LBHistorySize         int    `envconfig:"LB_HISTORY_SIZE" default:"100"`
+
+	// WebSocket sessions queue up to WSQueueSize outbound messages, then
+	// apply WSOverflowPolicy: block, drop-oldest or disconnect.
+	WSQueueSize      int           `envconfig:"WS_QUEUE_SIZE" default:"64"`
+	WSOverflowPolicy string        `envconfig:"WS_OVERFLOW_POLICY" default:"block"`
+	WSPingInterval   time.Duration `envconfig:"WS_PING_INTERVAL" default:"27s"`
+	WSPongWait       time.Duration `envconfig:"WS_PONG_WAIT" default:"30s"`
+	WSWriteWait      time.Duration `envconfig:"WS_WRITE_WAIT" default:"10s"`
+
+	// WSMuxMaxStreams bounds the concurrent streams of a /ws/mux session.
+	WSMuxMaxStreams int `envconfig:"WS_MUX_MAX_STREAMS" default:"100"`
+
+	ShutdownDrainPeriod time.Duration `envconfig:"SHUTDOWN_DRAIN_PERIOD" default:"5s"`
+	ShutdownTimeout     time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"30s"`
+
+	GRPCServerTLSCAFile             string `envconfig:"GRPC_SERVER_TLS_CA_FILE"`
+	GRPCServerTLSServerName         string `envconfig:"GRPC_SERVER_TLS_SERVER_NAME"`
+	GRPCServerTLSMinVersion         string `envconfig:"GRPC_SERVER_TLS_MIN_VERSION" default:"1.2"`
+	GRPCServerTLSInsecureSkipVerify bool   `envconfig:"GRPC_SERVER_TLS_INSECURE_SKIP_VERIFY" default:"false"`
+	GRPCClientTLSCertFile           string `envconfig:"GRPC_CLIENT_TLS_CERT_FILE"`
+	GRPCClientTLSKeyFile            string `envconfig:"GRPC_CLIENT_TLS_KEY_FILE"`
+
+	TracingExporter     string  `envconfig:"TRACING_EXPORTER" default:"none"`
+	TracingServiceName  string  `envconfig:"TRACING_SERVICE_NAME" default:"echo-grpc-client"`
+	TracingOTLPEndpoint string  `envconfig:"TRACING_OTLP_ENDPOINT" default:"localhost:4317"`
+	TracingOTLPInsecure bool    `envconfig:"TRACING_OTLP_INSECURE" default:"true"`
+	TracingFilePath     string  `envconfig:"TRACING_FILE_PATH" default:"traces.json"`
+	TracingSampleRatio  float64 `envconfig:"TRACING_SAMPLE_RATIO" default:"1"`
 }
 
 func NewSettings() (Settings, error) {
@@ -0,0 +1,54 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/zufardhiyaulhaq/echo-grpc/client/pkg/settings"
+)
+
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// New builds the TLS config used to dial the gRPC server. Without a CA file
+// the server certificate is verified against the system roots.
+func New(settings settings.Settings) (*tls.Config, error) {
+	minVersion, ok := versions[settings.GRPCServerTLSMinVersion]
+	if !ok {
+		return nil, fmt.Errorf("unknown TLS version %q", settings.GRPCServerTLSMinVersion)
+	}
+
+	config := &tls.Config{
+		MinVersion:         minVersion,
+		ServerName:         settings.GRPCServerTLSServerName,
+		InsecureSkipVerify: settings.GRPCServerTLSInsecureSkipVerify,
+	}
+
+	if settings.GRPCServerTLSCAFile != "" {
+		pem, err := os.ReadFile(settings.GRPCServerTLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", settings.GRPCServerTLSCAFile)
+		}
+	}
+
+	if settings.GRPCClientTLSCertFile != "" || settings.GRPCClientTLSKeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(settings.GRPCClientTLSCertFile, settings.GRPCClientTLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client key pair: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,4 @@
+package main
+
+// Build information, set through -ldflags by the Makefile.
+var version = "dev"
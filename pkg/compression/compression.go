@@ -0,0 +1,35 @@
+// Package compression registers the gzip and zstd gRPC compressors and
+// reports which compression a server call negotiated. Both binaries import it
+// so either side can decode whatever the other sends.
+package compression
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+// Identity is what a call without compression reports.
+const Identity = "identity"
+
+// Encodings returns the grpc-encoding the caller used for its messages and
+// the one the server replies with, taking SetSendCompressor into account.
+func Encodings(ctx context.Context) (request, response string) {
+	stream, ok := grpc.ServerTransportStreamFromContext(ctx).(interface {
+		RecvCompress() string
+		SendCompress() string
+	})
+	if !ok {
+		return Identity, Identity
+	}
+
+	return orIdentity(stream.RecvCompress()), orIdentity(stream.SendCompress())
+}
+
+func orIdentity(name string) string {
+	if name == "" {
+		return Identity
+	}
+	return name
+}
@@ -0,0 +1,213 @@
+package payload
+
+import (
+	crand "crypto/rand"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	DistributionFixed       = "fixed"
+	DistributionUniform     = "uniform"
+	DistributionNormal      = "normal"
+	DistributionExponential = "exponential"
+)
+
+const (
+	ContentZeros   = "zeros"
+	ContentRandom  = "random"
+	ContentPattern = "pattern"
+	ContentText    = "text"
+)
+
+// Metadata keys callers use to ask for a generated response payload.
+const (
+	MetadataSize         = "x-echo-payload-size"
+	MetadataSizeMin      = "x-echo-payload-size-min"
+	MetadataSizeMax      = "x-echo-payload-size-max"
+	MetadataSizeStddev   = "x-echo-payload-size-stddev"
+	MetadataDistribution = "x-echo-payload-distribution"
+	MetadataContent      = "x-echo-payload-content"
+	MetadataPattern      = "x-echo-payload-pattern"
+)
+
+// MaxSize caps every generated payload so a single call cannot exhaust the
+// server's memory.
+const MaxSize = 64 << 20
+
+// Spec describes the size and content of generated payloads.
+type Spec struct {
+	Distribution string
+	// Size is the fixed size, or the mean of the normal and exponential
+	// distributions.
+	Size   int
+	Min    int
+	Max    int
+	Stddev int
+
+	Content string
+	// Pattern is repeated by ContentPattern.
+	Pattern string
+}
+
+func (s Spec) Validate() error {
+	for name, size := range map[string]int{
+		"size":   s.Size,
+		"min":    s.Min,
+		"max":    s.Max,
+		"stddev": s.Stddev,
+	} {
+		if size < 0 || size > MaxSize {
+			return fmt.Errorf("payload %s must be between 0 and %d, got %d", name, MaxSize, size)
+		}
+	}
+
+	switch s.Distribution {
+	case DistributionFixed, DistributionNormal, DistributionExponential:
+	case DistributionUniform:
+		if s.Max < s.Min {
+			return fmt.Errorf("payload size max %d is lower than min %d", s.Max, s.Min)
+		}
+	default:
+		return fmt.Errorf("unknown payload distribution %q", s.Distribution)
+	}
+
+	switch s.Content {
+	case ContentZeros, ContentRandom, ContentText:
+	case ContentPattern:
+		if s.Pattern == "" {
+			return fmt.Errorf("payload pattern must not be empty")
+		}
+	default:
+		return fmt.Errorf("unknown payload content %q", s.Content)
+	}
+
+	return nil
+}
+
+// FromMetadata builds the Spec a caller asked for. ok is false when the call
+// carries no payload metadata at all.
+func FromMetadata(md metadata.MD) (spec Spec, ok bool, err error) {
+	spec = Spec{
+		Distribution: DistributionFixed,
+		Content:      ContentZeros,
+		Pattern:      "echo",
+	}
+
+	for key, target := range map[string]*int{
+		MetadataSize:       &spec.Size,
+		MetadataSizeMin:    &spec.Min,
+		MetadataSizeMax:    &spec.Max,
+		MetadataSizeStddev: &spec.Stddev,
+	} {
+		value := first(md, key)
+		if value == "" {
+			continue
+		}
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return spec, false, fmt.Errorf("invalid %s: %q", key, value)
+		}
+		*target = size
+		ok = true
+	}
+
+	if value := first(md, MetadataDistribution); value != "" {
+		spec.Distribution = strings.ToLower(value)
+		ok = true
+	}
+	if value := first(md, MetadataContent); value != "" {
+		spec.Content = strings.ToLower(value)
+		ok = true
+	}
+	if value := first(md, MetadataPattern); value != "" {
+		spec.Pattern = value
+		ok = true
+	}
+
+	if !ok {
+		return spec, false, nil
+	}
+
+	return spec, true, spec.Validate()
+}
+
+// Generate returns a new payload with a size drawn from the distribution.
+func (s Spec) Generate() []byte {
+	return Fill(s.Content, s.Pattern, s.size())
+}
+
+func (s Spec) size() int {
+	var size float64
+
+	switch s.Distribution {
+	case DistributionUniform:
+		size = float64(s.Min)
+		if s.Max > s.Min {
+			size += float64(rand.Intn(s.Max - s.Min + 1))
+		}
+	case DistributionNormal:
+		size = float64(s.Size) + rand.NormFloat64()*float64(s.Stddev)
+	case DistributionExponential:
+		size = rand.ExpFloat64() * float64(s.Size)
+	default:
+		size = float64(s.Size)
+	}
+
+	switch {
+	case size < 0:
+		return 0
+	case size > MaxSize:
+		return MaxSize
+	}
+	return int(size)
+}
+
+// Fill returns size bytes of the given content: zeros, random bytes (which do
+// not compress), pattern repeated, or English-like text (which compresses
+// well).
+func Fill(content, pattern string, size int) []byte {
+	data := make([]byte, size)
+
+	switch content {
+	case ContentRandom:
+		crand.Read(data)
+	case ContentPattern:
+		repeat(data, pattern)
+	case ContentText:
+		text(data)
+	}
+
+	return data
+}
+
+func repeat(data []byte, pattern string) {
+	for i := 0; i < len(data); i += len(pattern) {
+		copy(data[i:], pattern)
+	}
+}
+
+var words = strings.Fields(`the quick brown fox jumps over lazy dog echo grpc
+	server client stream message request response payload proxy envoy load
+	balancer timeout retry status health metadata trailer header compression`)
+
+func text(data []byte) {
+	for i := 0; i < len(data); {
+		i += copy(data[i:], words[rand.Intn(len(words))])
+		if i < len(data) {
+			data[i] = ' '
+			i++
+		}
+	}
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package payload
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestSpecValidate(t *testing.T) {
+	valid := Spec{Distribution: DistributionFixed, Size: 10, Content: ContentZeros}
+
+	tests := []struct {
+		name    string
+		modify  func(*Spec)
+		wantErr string
+	}{
+		{name: "fixed", modify: func(*Spec) {}},
+		{name: "max size", modify: func(s *Spec) { s.Size = MaxSize }},
+		{name: "above max size", modify: func(s *Spec) { s.Size = MaxSize + 1 }, wantErr: "payload size"},
+		{name: "negative size", modify: func(s *Spec) { s.Size = -1 }, wantErr: "payload size"},
+		{name: "negative stddev", modify: func(s *Spec) { s.Stddev = -1 }, wantErr: "payload stddev"},
+		{name: "uniform", modify: func(s *Spec) { s.Distribution = DistributionUniform; s.Min, s.Max = 1, 2 }},
+		{
+			name:    "uniform max below min",
+			modify:  func(s *Spec) { s.Distribution = DistributionUniform; s.Min, s.Max = 2, 1 },
+			wantErr: "lower than min",
+		},
+		{name: "unknown distribution", modify: func(s *Spec) { s.Distribution = "poisson" }, wantErr: "distribution"},
+		{name: "pattern", modify: func(s *Spec) { s.Content = ContentPattern; s.Pattern = "ab" }},
+		{name: "empty pattern", modify: func(s *Spec) { s.Content = ContentPattern }, wantErr: "pattern"},
+		{name: "unknown content", modify: func(s *Spec) { s.Content = "ones" }, wantErr: "content"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := valid
+			tt.modify(&spec)
+
+			err := spec.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFromMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		md      metadata.MD
+		want    Spec
+		wantOK  bool
+		wantErr bool
+	}{
+		{
+			name: "none",
+			md:   metadata.Pairs("x-other", "1"),
+			want: Spec{Distribution: DistributionFixed, Content: ContentZeros, Pattern: "echo"},
+		},
+		{
+			name:   "size",
+			md:     metadata.Pairs(MetadataSize, "1024"),
+			want:   Spec{Distribution: DistributionFixed, Size: 1024, Content: ContentZeros, Pattern: "echo"},
+			wantOK: true,
+		},
+		{
+			name: "uniform pattern",
+			md: metadata.Pairs(
+				MetadataDistribution, "Uniform",
+				MetadataSizeMin, "10",
+				MetadataSizeMax, "20",
+				MetadataContent, "PATTERN",
+				MetadataPattern, "ab",
+			),
+			want:   Spec{Distribution: DistributionUniform, Min: 10, Max: 20, Content: ContentPattern, Pattern: "ab"},
+			wantOK: true,
+		},
+		{name: "invalid size", md: metadata.Pairs(MetadataSize, "big"), wantErr: true},
+		{name: "size above max", md: metadata.Pairs(MetadataSize, strconv.Itoa(MaxSize+1)), wantErr: true},
+		{name: "unknown content", md: metadata.Pairs(MetadataContent, "ones"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := FromMetadata(tt.md)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("FromMetadata() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromMetadata() failed: %v", err)
+			}
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("FromMetadata() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSpecSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     Spec
+		min, max int
+	}{
+		{name: "fixed", spec: Spec{Distribution: DistributionFixed, Size: 100}, min: 100, max: 100},
+		{name: "uniform", spec: Spec{Distribution: DistributionUniform, Min: 10, Max: 20}, min: 10, max: 20},
+		{name: "normal", spec: Spec{Distribution: DistributionNormal, Size: 10, Stddev: 1000}, min: 0, max: MaxSize},
+		{name: "exponential", spec: Spec{Distribution: DistributionExponential, Size: MaxSize}, min: 0, max: MaxSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				if got := tt.spec.size(); got < tt.min || got > tt.max {
+					t.Fatalf("size() = %d, want between %d and %d", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestFill(t *testing.T) {
+	if got := Fill(ContentZeros, "", 4); !bytes.Equal(got, make([]byte, 4)) {
+		t.Errorf("zeros = %v", got)
+	}
+	if got := Fill(ContentPattern, "abc", 7); string(got) != "abcabca" {
+		t.Errorf("pattern = %q, want abcabca", got)
+	}
+	if got := Fill(ContentRandom, "", 32); len(got) != 32 || bytes.Equal(got, make([]byte, 32)) {
+		t.Errorf("random = %v, want 32 random bytes", got)
+	}
+
+	got := Fill(ContentText, "", 100)
+	if len(got) != 100 {
+		t.Fatalf("text is %d bytes, want 100", len(got))
+	}
+	for _, word := range strings.Fields(string(got[:50])) {
+		if !strings.Contains(strings.Join(words, " "), word) {
+			t.Errorf("text contains %q, not from the word list", word)
+		}
+	}
+}
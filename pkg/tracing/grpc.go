@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+const instrumentationName = "github.com/zufardhiyaulhaq/echo-grpc/pkg/tracing"
+
+// streamMessage is implemented by the streaming request and response types.
+type streamMessage interface {
+	GetStreamId() string
+	GetSequenceNumber() int64
+}
+
+// StreamServerInterceptor records a span for every message sent or received
+// on a server stream, as a child of the RPC span.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{
+			ServerStream: ss,
+			messages:     newMessageTracer(info.FullMethod),
+		})
+	}
+}
+
+// StreamClientInterceptor records a span for every message sent or received
+// on a client stream, as a child of the RPC span.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, fullMethod string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, fullMethod, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return &clientStream{
+			ClientStream: cs,
+			messages:     newMessageTracer(fullMethod),
+		}, nil
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	messages *messageTracer
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	return s.messages.trace(s.Context(), "send", m, func() error {
+		return s.ServerStream.SendMsg(m)
+	})
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	return s.messages.trace(s.Context(), "receive", m, func() error {
+		return s.ServerStream.RecvMsg(m)
+	})
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	messages *messageTracer
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	return s.messages.trace(s.Context(), "send", m, func() error {
+		return s.ClientStream.SendMsg(m)
+	})
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	return s.messages.trace(s.Context(), "receive", m, func() error {
+		return s.ClientStream.RecvMsg(m)
+	})
+}
+
+type messageTracer struct {
+	tracer   trace.Tracer
+	method   string
+	sent     int64
+	received int64
+}
+
+func newMessageTracer(fullMethod string) *messageTracer {
+	return &messageTracer{
+		tracer: otel.Tracer(instrumentationName),
+		method: strings.TrimPrefix(fullMethod, "/"),
+	}
+}
+
+func (t *messageTracer) trace(ctx context.Context, direction string, m interface{}, fn func() error) error {
+	_, span := t.tracer.Start(ctx, t.method+"/"+direction, trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+
+	err := fn()
+	if err == io.EOF {
+		span.SetAttributes(attribute.Bool("rpc.stream.eof", true))
+		return err
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	var id int64
+	if direction == "send" {
+		t.sent++
+		id = t.sent
+	} else {
+		t.received++
+		id = t.received
+	}
+
+	span.SetAttributes(
+		attribute.String("rpc.message.type", direction),
+		attribute.Int64("rpc.message.id", id),
+	)
+	if message, ok := m.(streamMessage); ok {
+		span.SetAttributes(
+			attribute.String("echo.stream_id", message.GetStreamId()),
+			attribute.Int64("echo.sequence_number", message.GetSequenceNumber()),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,377 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.4
+// source: proto/admin.proto
+
+package proto
+
+import (
+	grpc_health_v1 "google.golang.org/grpc/health/grpc_health_v1"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SetServingStatusRequest struct {
+	state   protoimpl.MessageState                           `protogen:"open.v1"`
+	Service string                                           `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	Status  grpc_health_v1.HealthCheckResponse_ServingStatus `protobuf:"varint,2,opt,name=status,proto3,enum=grpc.health.v1.HealthCheckResponse_ServingStatus" json:"status,omitempty"`
+	// When set, the previous status is restored after this duration.
+	Duration      *durationpb.Duration `protobuf:"bytes,3,opt,name=duration,proto3" json:"duration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetServingStatusRequest) Reset() {
+	*x = SetServingStatusRequest{}
+	mi := &file_proto_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetServingStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetServingStatusRequest) ProtoMessage() {}
+
+func (x *SetServingStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetServingStatusRequest.ProtoReflect.Descriptor instead.
+func (*SetServingStatusRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SetServingStatusRequest) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *SetServingStatusRequest) GetStatus() grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if x != nil {
+		return x.Status
+	}
+	return grpc_health_v1.HealthCheckResponse_ServingStatus(0)
+}
+
+func (x *SetServingStatusRequest) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+type SetServingStatusResponse struct {
+	state          protoimpl.MessageState                           `protogen:"open.v1"`
+	Service        string                                           `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	Status         grpc_health_v1.HealthCheckResponse_ServingStatus `protobuf:"varint,2,opt,name=status,proto3,enum=grpc.health.v1.HealthCheckResponse_ServingStatus" json:"status,omitempty"`
+	PreviousStatus grpc_health_v1.HealthCheckResponse_ServingStatus `protobuf:"varint,3,opt,name=previous_status,json=previousStatus,proto3,enum=grpc.health.v1.HealthCheckResponse_ServingStatus" json:"previous_status,omitempty"`
+	RestoreAt      *timestamppb.Timestamp                           `protobuf:"bytes,4,opt,name=restore_at,json=restoreAt,proto3" json:"restore_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SetServingStatusResponse) Reset() {
+	*x = SetServingStatusResponse{}
+	mi := &file_proto_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetServingStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetServingStatusResponse) ProtoMessage() {}
+
+func (x *SetServingStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetServingStatusResponse.ProtoReflect.Descriptor instead.
+func (*SetServingStatusResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SetServingStatusResponse) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *SetServingStatusResponse) GetStatus() grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if x != nil {
+		return x.Status
+	}
+	return grpc_health_v1.HealthCheckResponse_ServingStatus(0)
+}
+
+func (x *SetServingStatusResponse) GetPreviousStatus() grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if x != nil {
+		return x.PreviousStatus
+	}
+	return grpc_health_v1.HealthCheckResponse_ServingStatus(0)
+}
+
+func (x *SetServingStatusResponse) GetRestoreAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RestoreAt
+	}
+	return nil
+}
+
+type ListServingStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServingStatusRequest) Reset() {
+	*x = ListServingStatusRequest{}
+	mi := &file_proto_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServingStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServingStatusRequest) ProtoMessage() {}
+
+func (x *ListServingStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServingStatusRequest.ProtoReflect.Descriptor instead.
+func (*ListServingStatusRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{2}
+}
+
+type ServiceStatus struct {
+	state         protoimpl.MessageState                           `protogen:"open.v1"`
+	Service       string                                           `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	Status        grpc_health_v1.HealthCheckResponse_ServingStatus `protobuf:"varint,2,opt,name=status,proto3,enum=grpc.health.v1.HealthCheckResponse_ServingStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServiceStatus) Reset() {
+	*x = ServiceStatus{}
+	mi := &file_proto_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceStatus) ProtoMessage() {}
+
+func (x *ServiceStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceStatus.ProtoReflect.Descriptor instead.
+func (*ServiceStatus) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ServiceStatus) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *ServiceStatus) GetStatus() grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if x != nil {
+		return x.Status
+	}
+	return grpc_health_v1.HealthCheckResponse_ServingStatus(0)
+}
+
+type ListServingStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Services      []*ServiceStatus       `protobuf:"bytes,1,rep,name=services,proto3" json:"services,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServingStatusResponse) Reset() {
+	*x = ListServingStatusResponse{}
+	mi := &file_proto_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServingStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServingStatusResponse) ProtoMessage() {}
+
+func (x *ListServingStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServingStatusResponse.ProtoReflect.Descriptor instead.
+func (*ListServingStatusResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListServingStatusResponse) GetServices() []*ServiceStatus {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+var File_proto_admin_proto protoreflect.FileDescriptor
+
+const file_proto_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x11proto/admin.proto\x12\x14com.gopay.echo.admin\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bgrpc/health/v1/health.proto\"\xb5\x01\n" +
+	"\x17SetServingStatusRequest\x12\x18\n" +
+	"\aservice\x18\x01 \x01(\tR\aservice\x12I\n" +
+	"\x06status\x18\x02 \x01(\x0e21.grpc.health.v1.HealthCheckResponse.ServingStatusR\x06status\x125\n" +
+	"\bduration\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\bduration\"\x96\x02\n" +
+	"\x18SetServingStatusResponse\x12\x18\n" +
+	"\aservice\x18\x01 \x01(\tR\aservice\x12I\n" +
+	"\x06status\x18\x02 \x01(\x0e21.grpc.health.v1.HealthCheckResponse.ServingStatusR\x06status\x12Z\n" +
+	"\x0fprevious_status\x18\x03 \x01(\x0e21.grpc.health.v1.HealthCheckResponse.ServingStatusR\x0epreviousStatus\x129\n" +
+	"\n" +
+	"restore_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\trestoreAt\"\x1a\n" +
+	"\x18ListServingStatusRequest\"t\n" +
+	"\rServiceStatus\x12\x18\n" +
+	"\aservice\x18\x01 \x01(\tR\aservice\x12I\n" +
+	"\x06status\x18\x02 \x01(\x0e21.grpc.health.v1.HealthCheckResponse.ServingStatusR\x06status\"\\\n" +
+	"\x19ListServingStatusResponse\x12?\n" +
+	"\bservices\x18\x01 \x03(\v2#.com.gopay.echo.admin.ServiceStatusR\bservices2\xf0\x01\n" +
+	"\x05Admin\x12q\n" +
+	"\x10SetServingStatus\x12-.com.gopay.echo.admin.SetServingStatusRequest\x1a..com.gopay.echo.admin.SetServingStatusResponse\x12t\n" +
+	"\x11ListServingStatus\x12..com.gopay.echo.admin.ListServingStatusRequest\x1a/.com.gopay.echo.admin.ListServingStatusResponseB,Z*github.com/zufardhiyaulhaq/echo-grpc/protob\x06proto3"
+
+var (
+	file_proto_admin_proto_rawDescOnce sync.Once
+	file_proto_admin_proto_rawDescData []byte
+)
+
+func file_proto_admin_proto_rawDescGZIP() []byte {
+	file_proto_admin_proto_rawDescOnce.Do(func() {
+		file_proto_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_admin_proto_rawDesc), len(file_proto_admin_proto_rawDesc)))
+	})
+	return file_proto_admin_proto_rawDescData
+}
+
+var file_proto_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_proto_admin_proto_goTypes = []any{
+	(*SetServingStatusRequest)(nil),                       // 0: com.gopay.echo.admin.SetServingStatusRequest
+	(*SetServingStatusResponse)(nil),                      // 1: com.gopay.echo.admin.SetServingStatusResponse
+	(*ListServingStatusRequest)(nil),                      // 2: com.gopay.echo.admin.ListServingStatusRequest
+	(*ServiceStatus)(nil),                                 // 3: com.gopay.echo.admin.ServiceStatus
+	(*ListServingStatusResponse)(nil),                     // 4: com.gopay.echo.admin.ListServingStatusResponse
+	(grpc_health_v1.HealthCheckResponse_ServingStatus)(0), // 5: grpc.health.v1.HealthCheckResponse.ServingStatus
+	(*durationpb.Duration)(nil),                           // 6: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil),                         // 7: google.protobuf.Timestamp
+}
+var file_proto_admin_proto_depIdxs = []int32{
+	5, // 0: com.gopay.echo.admin.SetServingStatusRequest.status:type_name -> grpc.health.v1.HealthCheckResponse.ServingStatus
+	6, // 1: com.gopay.echo.admin.SetServingStatusRequest.duration:type_name -> google.protobuf.Duration
+	5, // 2: com.gopay.echo.admin.SetServingStatusResponse.status:type_name -> grpc.health.v1.HealthCheckResponse.ServingStatus
+	5, // 3: com.gopay.echo.admin.SetServingStatusResponse.previous_status:type_name -> grpc.health.v1.HealthCheckResponse.ServingStatus
+	7, // 4: com.gopay.echo.admin.SetServingStatusResponse.restore_at:type_name -> google.protobuf.Timestamp
+	5, // 5: com.gopay.echo.admin.ServiceStatus.status:type_name -> grpc.health.v1.HealthCheckResponse.ServingStatus
+	3, // 6: com.gopay.echo.admin.ListServingStatusResponse.services:type_name -> com.gopay.echo.admin.ServiceStatus
+	0, // 7: com.gopay.echo.admin.Admin.SetServingStatus:input_type -> com.gopay.echo.admin.SetServingStatusRequest
+	2, // 8: com.gopay.echo.admin.Admin.ListServingStatus:input_type -> com.gopay.echo.admin.ListServingStatusRequest
+	1, // 9: com.gopay.echo.admin.Admin.SetServingStatus:output_type -> com.gopay.echo.admin.SetServingStatusResponse
+	4, // 10: com.gopay.echo.admin.Admin.ListServingStatus:output_type -> com.gopay.echo.admin.ListServingStatusResponse
+	9, // [9:11] is the sub-list for method output_type
+	7, // [7:9] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_proto_admin_proto_init() }
+func file_proto_admin_proto_init() {
+	if File_proto_admin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_admin_proto_rawDesc), len(file_proto_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_admin_proto_goTypes,
+		DependencyIndexes: file_proto_admin_proto_depIdxs,
+		MessageInfos:      file_proto_admin_proto_msgTypes,
+	}.Build()
+	File_proto_admin_proto = out.File
+	file_proto_admin_proto_goTypes = nil
+	file_proto_admin_proto_depIdxs = nil
+}
@@ -0,0 +1,53 @@
+package proto
+
+import (
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// The grpc.health.v1 types used to be generated here from
+// proto/healthcheck.proto. grpc-go registers the same descriptors in
+// google.golang.org/grpc/health/grpc_health_v1, and linking both panics at
+// startup, so the local copy was removed and these aliases keep existing
+// imports compiling. The wire format is unchanged.
+//
+// Deprecated: use google.golang.org/grpc/health/grpc_health_v1.
+type (
+	HealthCheckRequest                = healthpb.HealthCheckRequest
+	HealthCheckResponse               = healthpb.HealthCheckResponse
+	HealthCheckResponse_ServingStatus = healthpb.HealthCheckResponse_ServingStatus
+	HealthClient                      = healthpb.HealthClient
+	HealthServer                      = healthpb.HealthServer
+	UnimplementedHealthServer         = healthpb.UnimplementedHealthServer
+	UnsafeHealthServer                = healthpb.UnsafeHealthServer
+	Health_WatchClient                = healthpb.Health_WatchClient
+	Health_WatchServer                = healthpb.Health_WatchServer
+)
+
+// Deprecated: use google.golang.org/grpc/health/grpc_health_v1.
+const (
+	HealthCheckResponse_UNKNOWN         = healthpb.HealthCheckResponse_UNKNOWN
+	HealthCheckResponse_SERVING         = healthpb.HealthCheckResponse_SERVING
+	HealthCheckResponse_NOT_SERVING     = healthpb.HealthCheckResponse_NOT_SERVING
+	HealthCheckResponse_SERVICE_UNKNOWN = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+
+	Health_Check_FullMethodName = healthpb.Health_Check_FullMethodName
+	Health_Watch_FullMethodName = healthpb.Health_Watch_FullMethodName
+)
+
+// Deprecated: use google.golang.org/grpc/health/grpc_health_v1.
+var (
+	HealthCheckResponse_ServingStatus_name  = healthpb.HealthCheckResponse_ServingStatus_name
+	HealthCheckResponse_ServingStatus_value = healthpb.HealthCheckResponse_ServingStatus_value
+	Health_ServiceDesc                      = healthpb.Health_ServiceDesc
+)
+
+// Deprecated: use google.golang.org/grpc/health/grpc_health_v1.NewHealthClient.
+func NewHealthClient(cc grpc.ClientConnInterface) HealthClient {
+	return healthpb.NewHealthClient(cc)
+}
+
+// Deprecated: use google.golang.org/grpc/health/grpc_health_v1.RegisterHealthServer.
+func RegisterHealthServer(s grpc.ServiceRegistrar, srv HealthServer) {
+	healthpb.RegisterHealthServer(s, srv)
+}
@@ -1,7 +1,7 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.26.0
-// 	protoc        v3.17.3
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.4
 // source: proto/server.proto
 
 package proto
@@ -9,8 +9,10 @@ package proto
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	reflect "reflect"
 	sync "sync"
+	unsafe "unsafe"
 )
 
 const (
@@ -21,20 +23,18 @@ const (
 )
 
 type Message struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`
 	unknownFields protoimpl.UnknownFields
-
-	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Message) Reset() {
 	*x = Message{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_server_proto_msgTypes[0]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_proto_server_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *Message) String() string {
@@ -45,7 +45,7 @@ func (*Message) ProtoMessage() {}
 
 func (x *Message) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_server_proto_msgTypes[0]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -67,22 +67,38 @@ func (x *Message) GetMessage() string {
 	return ""
 }
 
-type Response struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
+func (x *Message) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
 
-	Success  bool   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Response string `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+type Response struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Success  bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Response string                 `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	// The request payload, or a generated one when x-echo-payload-* metadata
+	// is set.
+	Payload []byte `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	// grpc-encoding of the request and of this response, "identity" when
+	// uncompressed.
+	RequestEncoding  string `protobuf:"bytes,4,opt,name=request_encoding,json=requestEncoding,proto3" json:"request_encoding,omitempty"`
+	ResponseEncoding string `protobuf:"bytes,5,opt,name=response_encoding,json=responseEncoding,proto3" json:"response_encoding,omitempty"`
+	// grpc-previous-rpc-attempts the client sent, non zero for retries and
+	// hedged calls.
+	PreviousRpcAttempts int32 `protobuf:"varint,6,opt,name=previous_rpc_attempts,json=previousRpcAttempts,proto3" json:"previous_rpc_attempts,omitempty"`
+	// Subject of the verified client certificate, empty without mTLS.
+	ClientCertificateSubject string `protobuf:"bytes,7,opt,name=client_certificate_subject,json=clientCertificateSubject,proto3" json:"client_certificate_subject,omitempty"`
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
 }
 
 func (x *Response) Reset() {
 	*x = Response{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_server_proto_msgTypes[1]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_proto_server_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *Response) String() string {
@@ -93,7 +109,7 @@ func (*Response) ProtoMessage() {}
 
 func (x *Response) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_server_proto_msgTypes[1]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -122,53 +138,476 @@ func (x *Response) GetResponse() string {
 	return ""
 }
 
-var File_proto_server_proto protoreflect.FileDescriptor
+func (x *Response) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Response) GetRequestEncoding() string {
+	if x != nil {
+		return x.RequestEncoding
+	}
+	return ""
+}
+
+func (x *Response) GetResponseEncoding() string {
+	if x != nil {
+		return x.ResponseEncoding
+	}
+	return ""
+}
+
+func (x *Response) GetPreviousRpcAttempts() int32 {
+	if x != nil {
+		return x.PreviousRpcAttempts
+	}
+	return 0
+}
+
+func (x *Response) GetClientCertificateSubject() string {
+	if x != nil {
+		return x.ClientCertificateSubject
+	}
+	return ""
+}
+
+type MetadataValues struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []string               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetadataValues) Reset() {
+	*x = MetadataValues{}
+	mi := &file_proto_server_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetadataValues) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetadataValues) ProtoMessage() {}
+
+func (x *MetadataValues) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_server_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetadataValues.ProtoReflect.Descriptor instead.
+func (*MetadataValues) Descriptor() ([]byte, []int) {
+	return file_proto_server_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MetadataValues) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type TLSInfo struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Version            string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	CipherSuite        string                 `protobuf:"bytes,2,opt,name=cipher_suite,json=cipherSuite,proto3" json:"cipher_suite,omitempty"`
+	ServerName         string                 `protobuf:"bytes,3,opt,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`
+	NegotiatedProtocol string                 `protobuf:"bytes,4,opt,name=negotiated_protocol,json=negotiatedProtocol,proto3" json:"negotiated_protocol,omitempty"`
+	// Set when the client presented a certificate that passed verification
+	// against the server's client CA bundle.
+	ClientCertificateVerified bool     `protobuf:"varint,5,opt,name=client_certificate_verified,json=clientCertificateVerified,proto3" json:"client_certificate_verified,omitempty"`
+	ClientCertificateSubject  string   `protobuf:"bytes,6,opt,name=client_certificate_subject,json=clientCertificateSubject,proto3" json:"client_certificate_subject,omitempty"`
+	ClientCertificateDnsNames []string `protobuf:"bytes,7,rep,name=client_certificate_dns_names,json=clientCertificateDnsNames,proto3" json:"client_certificate_dns_names,omitempty"`
+	ClientCertificateUris     []string `protobuf:"bytes,8,rep,name=client_certificate_uris,json=clientCertificateUris,proto3" json:"client_certificate_uris,omitempty"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *TLSInfo) Reset() {
+	*x = TLSInfo{}
+	mi := &file_proto_server_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TLSInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TLSInfo) ProtoMessage() {}
+
+func (x *TLSInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_server_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TLSInfo.ProtoReflect.Descriptor instead.
+func (*TLSInfo) Descriptor() ([]byte, []int) {
+	return file_proto_server_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TLSInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *TLSInfo) GetCipherSuite() string {
+	if x != nil {
+		return x.CipherSuite
+	}
+	return ""
+}
+
+func (x *TLSInfo) GetServerName() string {
+	if x != nil {
+		return x.ServerName
+	}
+	return ""
+}
+
+func (x *TLSInfo) GetNegotiatedProtocol() string {
+	if x != nil {
+		return x.NegotiatedProtocol
+	}
+	return ""
+}
+
+func (x *TLSInfo) GetClientCertificateVerified() bool {
+	if x != nil {
+		return x.ClientCertificateVerified
+	}
+	return false
+}
+
+func (x *TLSInfo) GetClientCertificateSubject() string {
+	if x != nil {
+		return x.ClientCertificateSubject
+	}
+	return ""
+}
+
+func (x *TLSInfo) GetClientCertificateDnsNames() []string {
+	if x != nil {
+		return x.ClientCertificateDnsNames
+	}
+	return nil
+}
+
+func (x *TLSInfo) GetClientCertificateUris() []string {
+	if x != nil {
+		return x.ClientCertificateUris
+	}
+	return nil
+}
+
+type ServerInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hostname      string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	PodName       string                 `protobuf:"bytes,2,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	PodNamespace  string                 `protobuf:"bytes,3,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	NodeName      string                 `protobuf:"bytes,4,opt,name=node_name,json=nodeName,proto3" json:"node_name,omitempty"`
+	Version       string                 `protobuf:"bytes,5,opt,name=version,proto3" json:"version,omitempty"`
+	GitCommit     string                 `protobuf:"bytes,6,opt,name=git_commit,json=gitCommit,proto3" json:"git_commit,omitempty"`
+	BuildDate     string                 `protobuf:"bytes,7,opt,name=build_date,json=buildDate,proto3" json:"build_date,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerInfo) Reset() {
+	*x = ServerInfo{}
+	mi := &file_proto_server_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerInfo) ProtoMessage() {}
+
+func (x *ServerInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_server_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerInfo.ProtoReflect.Descriptor instead.
+func (*ServerInfo) Descriptor() ([]byte, []int) {
+	return file_proto_server_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ServerInfo) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *ServerInfo) GetPodName() string {
+	if x != nil {
+		return x.PodName
+	}
+	return ""
+}
+
+func (x *ServerInfo) GetPodNamespace() string {
+	if x != nil {
+		return x.PodNamespace
+	}
+	return ""
+}
+
+func (x *ServerInfo) GetNodeName() string {
+	if x != nil {
+		return x.NodeName
+	}
+	return ""
+}
+
+func (x *ServerInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ServerInfo) GetGitCommit() string {
+	if x != nil {
+		return x.GitCommit
+	}
+	return ""
+}
+
+func (x *ServerInfo) GetBuildDate() string {
+	if x != nil {
+		return x.BuildDate
+	}
+	return ""
+}
+
+type InspectResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Success  bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Response string                 `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	// All metadata received with the call, including pseudo headers such as
+	// :authority.
+	Metadata    map[string]*MetadataValues `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	PeerAddress string                     `protobuf:"bytes,4,opt,name=peer_address,json=peerAddress,proto3" json:"peer_address,omitempty"`
+	Authority   string                     `protobuf:"bytes,5,opt,name=authority,proto3" json:"authority,omitempty"`
+	// Unset when the call is plaintext.
+	Tls *TLSInfo `protobuf:"bytes,6,opt,name=tls,proto3" json:"tls,omitempty"`
+	// Unset when the caller did not set a deadline.
+	DeadlineRemaining *durationpb.Duration `protobuf:"bytes,7,opt,name=deadline_remaining,json=deadlineRemaining,proto3" json:"deadline_remaining,omitempty"`
+	Server            *ServerInfo          `protobuf:"bytes,8,opt,name=server,proto3" json:"server,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *InspectResponse) Reset() {
+	*x = InspectResponse{}
+	mi := &file_proto_server_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InspectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
 
-var file_proto_server_proto_rawDesc = []byte{
-	0x0a, 0x12, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0e, 0x63, 0x6f, 0x6d, 0x2e, 0x67, 0x6f, 0x70, 0x61, 0x79, 0x2e,
-	0x65, 0x63, 0x68, 0x6f, 0x22, 0x23, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
-	0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x40, 0x0a, 0x08, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12,
-	0x1a, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0x47, 0x0a, 0x06, 0x53,
-	0x65, 0x72, 0x76, 0x65, 0x72, 0x12, 0x3d, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x12, 0x17, 0x2e, 0x63, 0x6f, 0x6d, 0x2e, 0x67, 0x6f, 0x70, 0x61, 0x79, 0x2e, 0x65, 0x63,
-	0x68, 0x6f, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x18, 0x2e, 0x63, 0x6f, 0x6d,
-	0x2e, 0x67, 0x6f, 0x70, 0x61, 0x79, 0x2e, 0x65, 0x63, 0x68, 0x6f, 0x2e, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x42, 0x2c, 0x5a, 0x2a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
-	0x6f, 0x6d, 0x2f, 0x7a, 0x75, 0x66, 0x61, 0x72, 0x64, 0x68, 0x69, 0x79, 0x61, 0x75, 0x6c, 0x68,
-	0x61, 0x71, 0x2f, 0x65, 0x63, 0x68, 0x6f, 0x2d, 0x67, 0x72, 0x70, 0x63, 0x2f, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+func (*InspectResponse) ProtoMessage() {}
+
+func (x *InspectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_server_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
+// Deprecated: Use InspectResponse.ProtoReflect.Descriptor instead.
+func (*InspectResponse) Descriptor() ([]byte, []int) {
+	return file_proto_server_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *InspectResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *InspectResponse) GetResponse() string {
+	if x != nil {
+		return x.Response
+	}
+	return ""
+}
+
+func (x *InspectResponse) GetMetadata() map[string]*MetadataValues {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *InspectResponse) GetPeerAddress() string {
+	if x != nil {
+		return x.PeerAddress
+	}
+	return ""
+}
+
+func (x *InspectResponse) GetAuthority() string {
+	if x != nil {
+		return x.Authority
+	}
+	return ""
+}
+
+func (x *InspectResponse) GetTls() *TLSInfo {
+	if x != nil {
+		return x.Tls
+	}
+	return nil
+}
+
+func (x *InspectResponse) GetDeadlineRemaining() *durationpb.Duration {
+	if x != nil {
+		return x.DeadlineRemaining
+	}
+	return nil
+}
+
+func (x *InspectResponse) GetServer() *ServerInfo {
+	if x != nil {
+		return x.Server
+	}
+	return nil
+}
+
+var File_proto_server_proto protoreflect.FileDescriptor
+
+const file_proto_server_proto_rawDesc = "" +
+	"\n" +
+	"\x12proto/server.proto\x12\x0ecom.gopay.echo\x1a\x1egoogle/protobuf/duration.proto\"=\n" +
+	"\aMessage\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x18\n" +
+	"\apayload\x18\x02 \x01(\fR\apayload\"\xa4\x02\n" +
+	"\bResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x1a\n" +
+	"\bresponse\x18\x02 \x01(\tR\bresponse\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\fR\apayload\x12)\n" +
+	"\x10request_encoding\x18\x04 \x01(\tR\x0frequestEncoding\x12+\n" +
+	"\x11response_encoding\x18\x05 \x01(\tR\x10responseEncoding\x122\n" +
+	"\x15previous_rpc_attempts\x18\x06 \x01(\x05R\x13previousRpcAttempts\x12<\n" +
+	"\x1aclient_certificate_subject\x18\a \x01(\tR\x18clientCertificateSubject\"(\n" +
+	"\x0eMetadataValues\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\tR\x06values\"\x8f\x03\n" +
+	"\aTLSInfo\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12!\n" +
+	"\fcipher_suite\x18\x02 \x01(\tR\vcipherSuite\x12\x1f\n" +
+	"\vserver_name\x18\x03 \x01(\tR\n" +
+	"serverName\x12/\n" +
+	"\x13negotiated_protocol\x18\x04 \x01(\tR\x12negotiatedProtocol\x12>\n" +
+	"\x1bclient_certificate_verified\x18\x05 \x01(\bR\x19clientCertificateVerified\x12<\n" +
+	"\x1aclient_certificate_subject\x18\x06 \x01(\tR\x18clientCertificateSubject\x12?\n" +
+	"\x1cclient_certificate_dns_names\x18\a \x03(\tR\x19clientCertificateDnsNames\x126\n" +
+	"\x17client_certificate_uris\x18\b \x03(\tR\x15clientCertificateUris\"\xdd\x01\n" +
+	"\n" +
+	"ServerInfo\x12\x1a\n" +
+	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x19\n" +
+	"\bpod_name\x18\x02 \x01(\tR\apodName\x12#\n" +
+	"\rpod_namespace\x18\x03 \x01(\tR\fpodNamespace\x12\x1b\n" +
+	"\tnode_name\x18\x04 \x01(\tR\bnodeName\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\tR\aversion\x12\x1d\n" +
+	"\n" +
+	"git_commit\x18\x06 \x01(\tR\tgitCommit\x12\x1d\n" +
+	"\n" +
+	"build_date\x18\a \x01(\tR\tbuildDate\"\xd9\x03\n" +
+	"\x0fInspectResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x1a\n" +
+	"\bresponse\x18\x02 \x01(\tR\bresponse\x12I\n" +
+	"\bmetadata\x18\x03 \x03(\v2-.com.gopay.echo.InspectResponse.MetadataEntryR\bmetadata\x12!\n" +
+	"\fpeer_address\x18\x04 \x01(\tR\vpeerAddress\x12\x1c\n" +
+	"\tauthority\x18\x05 \x01(\tR\tauthority\x12)\n" +
+	"\x03tls\x18\x06 \x01(\v2\x17.com.gopay.echo.TLSInfoR\x03tls\x12H\n" +
+	"\x12deadline_remaining\x18\a \x01(\v2\x19.google.protobuf.DurationR\x11deadlineRemaining\x122\n" +
+	"\x06server\x18\b \x01(\v2\x1a.com.gopay.echo.ServerInfoR\x06server\x1a[\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x124\n" +
+	"\x05value\x18\x02 \x01(\v2\x1e.com.gopay.echo.MetadataValuesR\x05value:\x028\x012\x8c\x01\n" +
+	"\x06Server\x12=\n" +
+	"\bGetReply\x12\x17.com.gopay.echo.Message\x1a\x18.com.gopay.echo.Response\x12C\n" +
+	"\aInspect\x12\x17.com.gopay.echo.Message\x1a\x1f.com.gopay.echo.InspectResponseB,Z*github.com/zufardhiyaulhaq/echo-grpc/protob\x06proto3"
+
 var (
 	file_proto_server_proto_rawDescOnce sync.Once
-	file_proto_server_proto_rawDescData = file_proto_server_proto_rawDesc
+	file_proto_server_proto_rawDescData []byte
 )
 
 func file_proto_server_proto_rawDescGZIP() []byte {
 	file_proto_server_proto_rawDescOnce.Do(func() {
-		file_proto_server_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_server_proto_rawDescData)
+		file_proto_server_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_server_proto_rawDesc), len(file_proto_server_proto_rawDesc)))
 	})
 	return file_proto_server_proto_rawDescData
 }
 
-var file_proto_server_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
-var file_proto_server_proto_goTypes = []interface{}{
-	(*Message)(nil),  // 0: com.gopay.echo.Message
-	(*Response)(nil), // 1: com.gopay.echo.Response
+var file_proto_server_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_server_proto_goTypes = []any{
+	(*Message)(nil),             // 0: com.gopay.echo.Message
+	(*Response)(nil),            // 1: com.gopay.echo.Response
+	(*MetadataValues)(nil),      // 2: com.gopay.echo.MetadataValues
+	(*TLSInfo)(nil),             // 3: com.gopay.echo.TLSInfo
+	(*ServerInfo)(nil),          // 4: com.gopay.echo.ServerInfo
+	(*InspectResponse)(nil),     // 5: com.gopay.echo.InspectResponse
+	nil,                         // 6: com.gopay.echo.InspectResponse.MetadataEntry
+	(*durationpb.Duration)(nil), // 7: google.protobuf.Duration
 }
 var file_proto_server_proto_depIdxs = []int32{
-	0, // 0: com.gopay.echo.Server.GetReply:input_type -> com.gopay.echo.Message
-	1, // 1: com.gopay.echo.Server.GetReply:output_type -> com.gopay.echo.Response
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	6, // 0: com.gopay.echo.InspectResponse.metadata:type_name -> com.gopay.echo.InspectResponse.MetadataEntry
+	3, // 1: com.gopay.echo.InspectResponse.tls:type_name -> com.gopay.echo.TLSInfo
+	7, // 2: com.gopay.echo.InspectResponse.deadline_remaining:type_name -> google.protobuf.Duration
+	4, // 3: com.gopay.echo.InspectResponse.server:type_name -> com.gopay.echo.ServerInfo
+	2, // 4: com.gopay.echo.InspectResponse.MetadataEntry.value:type_name -> com.gopay.echo.MetadataValues
+	0, // 5: com.gopay.echo.Server.GetReply:input_type -> com.gopay.echo.Message
+	0, // 6: com.gopay.echo.Server.Inspect:input_type -> com.gopay.echo.Message
+	1, // 7: com.gopay.echo.Server.GetReply:output_type -> com.gopay.echo.Response
+	5, // 8: com.gopay.echo.Server.Inspect:output_type -> com.gopay.echo.InspectResponse
+	7, // [7:9] is the sub-list for method output_type
+	5, // [5:7] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_proto_server_proto_init() }
@@ -176,39 +615,13 @@ func file_proto_server_proto_init() {
 	if File_proto_server_proto != nil {
 		return
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_proto_server_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Message); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_proto_server_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Response); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_proto_server_proto_rawDesc,
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_server_proto_rawDesc), len(file_proto_server_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -217,7 +630,6 @@ func file_proto_server_proto_init() {
 		MessageInfos:      file_proto_server_proto_msgTypes,
 	}.Build()
 	File_proto_server_proto = out.File
-	file_proto_server_proto_rawDesc = nil
 	file_proto_server_proto_goTypes = nil
 	file_proto_server_proto_depIdxs = nil
 }
@@ -1,4 +1,8 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             v6.33.4
+// source: proto/server.proto
 
 package proto
 
@@ -11,14 +15,20 @@ import (
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the grpc package it is being compiled against.
-// Requires gRPC-Go v1.32.0 or later.
-const _ = grpc.SupportPackageIsVersion7
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Server_GetReply_FullMethodName = "/com.gopay.echo.Server/GetReply"
+	Server_Inspect_FullMethodName  = "/com.gopay.echo.Server/Inspect"
+)
 
 // ServerClient is the client API for Server service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ServerClient interface {
 	GetReply(ctx context.Context, in *Message, opts ...grpc.CallOption) (*Response, error)
+	Inspect(ctx context.Context, in *Message, opts ...grpc.CallOption) (*InspectResponse, error)
 }
 
 type serverClient struct {
@@ -30,8 +40,19 @@ func NewServerClient(cc grpc.ClientConnInterface) ServerClient {
 }
 
 func (c *serverClient) GetReply(ctx context.Context, in *Message, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Response)
-	err := c.cc.Invoke(ctx, "/com.gopay.echo.Server/GetReply", in, out, opts...)
+	err := c.cc.Invoke(ctx, Server_GetReply_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serverClient) Inspect(ctx context.Context, in *Message, opts ...grpc.CallOption) (*InspectResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InspectResponse)
+	err := c.cc.Invoke(ctx, Server_Inspect_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -40,20 +61,28 @@ func (c *serverClient) GetReply(ctx context.Context, in *Message, opts ...grpc.C
 
 // ServerServer is the server API for Server service.
 // All implementations must embed UnimplementedServerServer
-// for forward compatibility
+// for forward compatibility.
 type ServerServer interface {
 	GetReply(context.Context, *Message) (*Response, error)
+	Inspect(context.Context, *Message) (*InspectResponse, error)
 	mustEmbedUnimplementedServerServer()
 }
 
-// UnimplementedServerServer must be embedded to have forward compatible implementations.
-type UnimplementedServerServer struct {
-}
+// UnimplementedServerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedServerServer struct{}
 
 func (UnimplementedServerServer) GetReply(context.Context, *Message) (*Response, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetReply not implemented")
+	return nil, status.Error(codes.Unimplemented, "method GetReply not implemented")
+}
+func (UnimplementedServerServer) Inspect(context.Context, *Message) (*InspectResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Inspect not implemented")
 }
 func (UnimplementedServerServer) mustEmbedUnimplementedServerServer() {}
+func (UnimplementedServerServer) testEmbeddedByValue()                {}
 
 // UnsafeServerServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to ServerServer will
@@ -63,6 +92,13 @@ type UnsafeServerServer interface {
 }
 
 func RegisterServerServer(s grpc.ServiceRegistrar, srv ServerServer) {
+	// If the following call panics, it indicates UnimplementedServerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
 	s.RegisterService(&Server_ServiceDesc, srv)
 }
 
@@ -76,7 +112,7 @@ func _Server_GetReply_Handler(srv interface{}, ctx context.Context, dec func(int
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/com.gopay.echo.Server/GetReply",
+		FullMethod: Server_GetReply_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(ServerServer).GetReply(ctx, req.(*Message))
@@ -84,6 +120,24 @@ func _Server_GetReply_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Server_Inspect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Message)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerServer).Inspect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Server_Inspect_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerServer).Inspect(ctx, req.(*Message))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Server_ServiceDesc is the grpc.ServiceDesc for Server service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -95,6 +149,10 @@ var Server_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetReply",
 			Handler:    _Server_GetReply_Handler,
 		},
+		{
+			MethodName: "Inspect",
+			Handler:    _Server_Inspect_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/server.proto",
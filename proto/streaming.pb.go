@@ -9,6 +9,7 @@ package proto
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -27,6 +28,12 @@ type StreamMessage struct {
 	SequenceNumber int64                  `protobuf:"varint,2,opt,name=sequence_number,json=sequenceNumber,proto3" json:"sequence_number,omitempty"`
 	Timestamp      int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	Message        string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	// ServerStream only; unset fields fall back to the server defaults.
+	Count          int32                `protobuf:"varint,5,opt,name=count,proto3" json:"count,omitempty"`
+	Interval       *durationpb.Duration `protobuf:"bytes,6,opt,name=interval,proto3" json:"interval,omitempty"`
+	Jitter         *durationpb.Duration `protobuf:"bytes,7,opt,name=jitter,proto3" json:"jitter,omitempty"`
+	UntilCancelled bool                 `protobuf:"varint,8,opt,name=until_cancelled,json=untilCancelled,proto3" json:"until_cancelled,omitempty"`
+	Payload        []byte               `protobuf:"bytes,9,opt,name=payload,proto3" json:"payload,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -89,6 +96,41 @@ func (x *StreamMessage) GetMessage() string {
 	return ""
 }
 
+func (x *StreamMessage) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *StreamMessage) GetInterval() *durationpb.Duration {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+func (x *StreamMessage) GetJitter() *durationpb.Duration {
+	if x != nil {
+		return x.Jitter
+	}
+	return nil
+}
+
+func (x *StreamMessage) GetUntilCancelled() bool {
+	if x != nil {
+		return x.UntilCancelled
+	}
+	return false
+}
+
+func (x *StreamMessage) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
 type StreamResponse struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	StreamId       string                 `protobuf:"bytes,1,opt,name=stream_id,json=streamId,proto3" json:"stream_id,omitempty"`
@@ -96,8 +138,18 @@ type StreamResponse struct {
 	Timestamp      int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	Response       string                 `protobuf:"bytes,4,opt,name=response,proto3" json:"response,omitempty"`
 	Success        bool                   `protobuf:"varint,5,opt,name=success,proto3" json:"success,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// The request payload, or a generated one when x-echo-payload-* metadata
+	// is set.
+	Payload []byte `protobuf:"bytes,6,opt,name=payload,proto3" json:"payload,omitempty"`
+	// grpc-encoding of the request messages and of this response,
+	// "identity" when uncompressed.
+	RequestEncoding  string `protobuf:"bytes,7,opt,name=request_encoding,json=requestEncoding,proto3" json:"request_encoding,omitempty"`
+	ResponseEncoding string `protobuf:"bytes,8,opt,name=response_encoding,json=responseEncoding,proto3" json:"response_encoding,omitempty"`
+	// grpc-previous-rpc-attempts the client sent, non zero for retries and
+	// hedged calls.
+	PreviousRpcAttempts int32 `protobuf:"varint,9,opt,name=previous_rpc_attempts,json=previousRpcAttempts,proto3" json:"previous_rpc_attempts,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
 }
 
 func (x *StreamResponse) Reset() {
@@ -165,22 +217,59 @@ func (x *StreamResponse) GetSuccess() bool {
 	return false
 }
 
+func (x *StreamResponse) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetRequestEncoding() string {
+	if x != nil {
+		return x.RequestEncoding
+	}
+	return ""
+}
+
+func (x *StreamResponse) GetResponseEncoding() string {
+	if x != nil {
+		return x.ResponseEncoding
+	}
+	return ""
+}
+
+func (x *StreamResponse) GetPreviousRpcAttempts() int32 {
+	if x != nil {
+		return x.PreviousRpcAttempts
+	}
+	return 0
+}
+
 var File_proto_streaming_proto protoreflect.FileDescriptor
 
 const file_proto_streaming_proto_rawDesc = "" +
 	"\n" +
-	"\x15proto/streaming.proto\x12\x18com.gopay.echo.streaming\"\x8d\x01\n" +
+	"\x15proto/streaming.proto\x12\x18com.gopay.echo.streaming\x1a\x1egoogle/protobuf/duration.proto\"\xd0\x02\n" +
 	"\rStreamMessage\x12\x1b\n" +
 	"\tstream_id\x18\x01 \x01(\tR\bstreamId\x12'\n" +
 	"\x0fsequence_number\x18\x02 \x01(\x03R\x0esequenceNumber\x12\x1c\n" +
 	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x12\x18\n" +
-	"\amessage\x18\x04 \x01(\tR\amessage\"\xaa\x01\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12\x14\n" +
+	"\x05count\x18\x05 \x01(\x05R\x05count\x125\n" +
+	"\binterval\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\binterval\x121\n" +
+	"\x06jitter\x18\a \x01(\v2\x19.google.protobuf.DurationR\x06jitter\x12'\n" +
+	"\x0funtil_cancelled\x18\b \x01(\bR\x0euntilCancelled\x12\x18\n" +
+	"\apayload\x18\t \x01(\fR\apayload\"\xd0\x02\n" +
 	"\x0eStreamResponse\x12\x1b\n" +
 	"\tstream_id\x18\x01 \x01(\tR\bstreamId\x12'\n" +
 	"\x0fsequence_number\x18\x02 \x01(\x03R\x0esequenceNumber\x12\x1c\n" +
 	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x12\x1a\n" +
 	"\bresponse\x18\x04 \x01(\tR\bresponse\x12\x18\n" +
-	"\asuccess\x18\x05 \x01(\bR\asuccess2\xc9\x02\n" +
+	"\asuccess\x18\x05 \x01(\bR\asuccess\x12\x18\n" +
+	"\apayload\x18\x06 \x01(\fR\apayload\x12)\n" +
+	"\x10request_encoding\x18\a \x01(\tR\x0frequestEncoding\x12+\n" +
+	"\x11response_encoding\x18\b \x01(\tR\x10responseEncoding\x122\n" +
+	"\x15previous_rpc_attempts\x18\t \x01(\x05R\x13previousRpcAttempts2\xc9\x02\n" +
 	"\x0fStreamingServer\x12c\n" +
 	"\fClientStream\x12'.com.gopay.echo.streaming.StreamMessage\x1a(.com.gopay.echo.streaming.StreamResponse(\x01\x12c\n" +
 	"\fServerStream\x12'.com.gopay.echo.streaming.StreamMessage\x1a(.com.gopay.echo.streaming.StreamResponse0\x01\x12l\n" +
@@ -200,21 +289,24 @@ func file_proto_streaming_proto_rawDescGZIP() []byte {
 
 var file_proto_streaming_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_proto_streaming_proto_goTypes = []any{
-	(*StreamMessage)(nil),  // 0: com.gopay.echo.streaming.StreamMessage
-	(*StreamResponse)(nil), // 1: com.gopay.echo.streaming.StreamResponse
+	(*StreamMessage)(nil),       // 0: com.gopay.echo.streaming.StreamMessage
+	(*StreamResponse)(nil),      // 1: com.gopay.echo.streaming.StreamResponse
+	(*durationpb.Duration)(nil), // 2: google.protobuf.Duration
 }
 var file_proto_streaming_proto_depIdxs = []int32{
-	0, // 0: com.gopay.echo.streaming.StreamingServer.ClientStream:input_type -> com.gopay.echo.streaming.StreamMessage
-	0, // 1: com.gopay.echo.streaming.StreamingServer.ServerStream:input_type -> com.gopay.echo.streaming.StreamMessage
-	0, // 2: com.gopay.echo.streaming.StreamingServer.BidirectionalStream:input_type -> com.gopay.echo.streaming.StreamMessage
-	1, // 3: com.gopay.echo.streaming.StreamingServer.ClientStream:output_type -> com.gopay.echo.streaming.StreamResponse
-	1, // 4: com.gopay.echo.streaming.StreamingServer.ServerStream:output_type -> com.gopay.echo.streaming.StreamResponse
-	1, // 5: com.gopay.echo.streaming.StreamingServer.BidirectionalStream:output_type -> com.gopay.echo.streaming.StreamResponse
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	2, // 0: com.gopay.echo.streaming.StreamMessage.interval:type_name -> google.protobuf.Duration
+	2, // 1: com.gopay.echo.streaming.StreamMessage.jitter:type_name -> google.protobuf.Duration
+	0, // 2: com.gopay.echo.streaming.StreamingServer.ClientStream:input_type -> com.gopay.echo.streaming.StreamMessage
+	0, // 3: com.gopay.echo.streaming.StreamingServer.ServerStream:input_type -> com.gopay.echo.streaming.StreamMessage
+	0, // 4: com.gopay.echo.streaming.StreamingServer.BidirectionalStream:input_type -> com.gopay.echo.streaming.StreamMessage
+	1, // 5: com.gopay.echo.streaming.StreamingServer.ClientStream:output_type -> com.gopay.echo.streaming.StreamResponse
+	1, // 6: com.gopay.echo.streaming.StreamingServer.ServerStream:output_type -> com.gopay.echo.streaming.StreamResponse
+	1, // 7: com.gopay.echo.streaming.StreamingServer.BidirectionalStream:output_type -> com.gopay.echo.streaming.StreamResponse
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_proto_streaming_proto_init() }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/health"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type AdminServer struct {
+	pb.UnimplementedAdminServer
+	registry *health.Registry
+}
+
+func NewAdminServer(registry *health.Registry) *AdminServer {
+	return &AdminServer{
+		registry: registry,
+	}
+}
+
+func (s *AdminServer) SetServingStatus(ctx context.Context, req *pb.SetServingStatusRequest) (*pb.SetServingStatusResponse, error) {
+	switch req.Status {
+	case healthpb.HealthCheckResponse_SERVING, healthpb.HealthCheckResponse_NOT_SERVING, healthpb.HealthCheckResponse_UNKNOWN:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "status %s can not be set", req.Status)
+	}
+
+	response := &pb.SetServingStatusResponse{
+		Service: req.Service,
+		Status:  req.Status,
+	}
+
+	if req.Duration != nil {
+		if err := req.Duration.CheckValid(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid duration: %v", err)
+		}
+
+		duration := req.Duration.AsDuration()
+		if duration <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "duration must be positive")
+		}
+
+		previous, err := s.registry.SetServingStatusFor(req.Service, req.Status, duration)
+		if err != nil {
+			return nil, registryError(err)
+		}
+		response.PreviousStatus = previous
+		response.RestoreAt = timestamppb.New(time.Now().Add(duration))
+	} else {
+		previous, ok := s.registry.Status(req.Service)
+		if !ok {
+			previous = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+
+		if err := s.registry.SetServingStatus(req.Service, req.Status); err != nil {
+			return nil, registryError(err)
+		}
+		response.PreviousStatus = previous
+	}
+
+	log.Info().
+		Str("service", req.Service).
+		Str("status", req.Status.String()).
+		Str("previous_status", response.PreviousStatus.String()).
+		Dur("duration", req.Duration.AsDuration()).
+		Msg("admin: serving status changed")
+
+	return response, nil
+}
+
+func (s *AdminServer) ListServingStatus(ctx context.Context, req *pb.ListServingStatusRequest) (*pb.ListServingStatusResponse, error) {
+	response := &pb.ListServingStatusResponse{}
+	for service, servingStatus := range s.registry.Services() {
+		response.Services = append(response.Services, &pb.ServiceStatus{
+			Service: service,
+			Status:  servingStatus,
+		})
+	}
+
+	sort.Slice(response.Services, func(i, j int) bool {
+		return response.Services[i].Service < response.Services[j].Service
+	})
+
+	return response, nil
+}
+
+func registryError(err error) error {
+	if errors.Is(err, health.ErrShutdown) {
+		return status.Error(codes.FailedPrecondition, "server is shutting down, serving status can not be changed")
+	}
+	return status.Error(codes.Internal, err.Error())
+}
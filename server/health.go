@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/health"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type HealthServer struct {
+	healthpb.UnimplementedHealthServer
+	registry *health.Registry
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewHealthServer(registry *health.Registry) *HealthServer {
+	return &HealthServer{
+		registry: registry,
+		done:     make(chan struct{}),
+	}
+}
+
+// Stop ends every Watch stream so they do not hold up a graceful stop.
+func (s *HealthServer) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *HealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	servingStatus, ok := s.registry.Status(req.Service)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+
+	return &healthpb.HealthCheckResponse{
+		Status: servingStatus,
+	}, nil
+}
+
+func (s *HealthServer) Watch(req *healthpb.HealthCheckRequest, watch healthpb.Health_WatchServer) error {
+	updates, unsubscribe := s.registry.Subscribe(req.Service)
+	defer unsubscribe()
+
+	log.Info().
+		Str("service", req.Service).
+		Msg("health watch: subscribed")
+
+	var lastStatus healthpb.HealthCheckResponse_ServingStatus = -1
+	for {
+		select {
+		case servingStatus := <-updates:
+			if servingStatus == lastStatus {
+				continue
+			}
+			lastStatus = servingStatus
+
+			if err := watch.Send(&healthpb.HealthCheckResponse{Status: servingStatus}); err != nil {
+				return err
+			}
+		case <-s.done:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-watch.Context().Done():
+			log.Info().
+				Str("service", req.Service).
+				Msg("health watch: subscriber disconnected")
+			return status.Error(codes.Canceled, "stream has ended")
+		}
+	}
+}
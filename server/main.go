@@ -1,13 +1,33 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/zufardhiyaulhaq/echo-grpc/pkg/tracing"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/behavior"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/certs"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/connection"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/fault"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/grpcweb"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/health"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/loadreport"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/metrics"
 	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/settings"
 
 	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 )
@@ -15,32 +35,261 @@ import (
 func main() {
 	settings, err := settings.NewSettings()
 	if err != nil {
-		log.Fatal().AnErr("failed to get settings", err)
+		log.Fatal().Err(err).Msg("failed to get settings")
 	}
 
 	log.Info().Msg("starting grpc server")
 
 	listener, err := net.Listen("tcp", "0.0.0.0:"+settings.Port)
 	if err != nil {
-		log.Fatal().AnErr("failed to listen connection", err)
+		log.Fatal().Err(err).Msg("failed to listen connection")
 	}
 
-	var opts []grpc.ServerOption
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName:    settings.TracingServiceName,
+		ServiceVersion: version,
+		Exporter:       settings.TracingExporter,
+		OTLPEndpoint:   settings.TracingOTLPEndpoint,
+		OTLPInsecure:   settings.TracingOTLPInsecure,
+		FilePath:       settings.TracingFilePath,
+		SampleRatio:    settings.TracingSampleRatio,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to set up tracing")
+	}
+	defer shutdownTracing(context.Background())
+
+	opts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	}
+
+	creds := insecure.NewCredentials()
+	var tlsConfig *tls.Config
+	if settings.GRPCTLS {
+		log.Info().Msg("setting gRPC to serve with TLS")
+
+		clientAuth := settings.GRPCTLSClientAuth
+		if clientAuth == "" {
+			clientAuth = "none"
+			if settings.GRPCTLSClientCAFile != "" {
+				clientAuth = "require-and-verify"
+			}
+		}
+
+		clientAuthType, err := certs.ParseClientAuth(clientAuth)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid TLS client auth")
+		}
+
+		reloader, err := certs.NewReloader(settings.GRPCTLSCertFile, settings.GRPCTLSKeyFile, settings.GRPCTLSClientCAFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load TLS certificates")
+		}
+
+		if settings.GRPCTLSReloadInterval > 0 {
+			go reloader.Watch(context.Background(), settings.GRPCTLSReloadInterval)
+		}
+
+		tlsConfig = reloader.TLSConfig(clientAuthType)
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	opts = append(opts, grpc.Creds(connection.Credentials(creds)))
 
+	keepaliveParams := keepalive.ServerParameters{
+		MaxConnectionIdle:     settings.GRPCMaxConnectionIdle,
+		MaxConnectionAge:      settings.GRPCMaxConnectionAge,
+		MaxConnectionAgeGrace: settings.GRPCMaxConnectionAgeGrace,
+	}
 	if settings.GRPCKeepalive {
 		log.Info().Msg("setting gRPC to enable keepalive")
-		keepaliveParams := keepalive.ServerParameters{
-			Time:    settings.GRPCKeepaliveTime,
-			Timeout: settings.GRPCKeepaliveTimeout,
-		}
-		opts = append(opts, grpc.KeepaliveParams(keepaliveParams))
+		keepaliveParams.Time = settings.GRPCKeepaliveTime
+		keepaliveParams.Timeout = settings.GRPCKeepaliveTimeout
+	}
+	log.Info().
+		Dur("max_connection_idle", settings.GRPCMaxConnectionIdle).
+		Dur("max_connection_age", settings.GRPCMaxConnectionAge).
+		Dur("max_connection_age_grace", settings.GRPCMaxConnectionAgeGrace).
+		Dur("keepalive_min_time", settings.GRPCKeepaliveMinTime).
+		Bool("keepalive_permit_without_stream", settings.GRPCKeepalivePermitWithoutStream).
+		Msg("setting gRPC connection lifecycle")
+	opts = append(opts,
+		grpc.KeepaliveParams(keepaliveParams),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             settings.GRPCKeepaliveMinTime,
+			PermitWithoutStream: settings.GRPCKeepalivePermitWithoutStream,
+		}),
+	)
+
+	if settings.LoadReportCPUUtilization > 0 {
+		log.Info().
+			Float64("cpu_utilization", settings.LoadReportCPUUtilization).
+			Msg("setting gRPC to attach ORCA load reports")
+		reporter := loadreport.NewReporter(settings.LoadReportCPUUtilization)
+		go reporter.Run(context.Background(), time.Second)
+		opts = append(opts,
+			reporter.ServerOption(),
+			grpc.ChainUnaryInterceptor(reporter.UnaryServerInterceptor()),
+			grpc.ChainStreamInterceptor(reporter.StreamServerInterceptor()),
+		)
 	}
 
+	echoServices := []string{
+		pb.Server_ServiceDesc.ServiceName,
+		pb.StreamingServer_ServiceDesc.ServiceName,
+	}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(
+			metrics.UnaryServerInterceptor(),
+			behavior.UnaryServerInterceptor(echoServices...),
+		),
+		grpc.ChainStreamInterceptor(
+			metrics.StreamServerInterceptor(),
+			tracing.StreamServerInterceptor(),
+			behavior.StreamServerInterceptor(echoServices...),
+		),
+	)
+
 	grpcServer := grpc.NewServer(opts...)
 
-	pb.RegisterServerServer(grpcServer, NewServer())
-	pb.RegisterHealthServer(grpcServer, NewServer())
-	pb.RegisterStreamingServerServer(grpcServer, NewStreamingServer())
+	healthRegistry := health.NewRegistry()
+	healthRegistry.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthRegistry.SetServingStatus(pb.Server_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthRegistry.SetServingStatus(pb.StreamingServer_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	abortCode, err := fault.ParseCode(settings.FaultAbortCode)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid fault abort code")
+	}
+
+	injector, err := fault.NewInjector(fault.Config{
+		AbortPercent:      settings.FaultAbortPercent,
+		AbortCode:         abortCode,
+		AbortMessage:      settings.FaultAbortMessage,
+		DelayPercent:      settings.FaultDelayPercent,
+		DelayDistribution: settings.FaultDelayDistribution,
+		Delay:             settings.FaultDelay,
+		DelayMin:          settings.FaultDelayMin,
+		DelayMax:          settings.FaultDelayMax,
+		DelayStddev:       settings.FaultDelayStddev,
+		DropPercent:       settings.FaultDropPercent,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid fault injection settings")
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get hostname")
+	}
+
+	serverInfo := &pb.ServerInfo{
+		Hostname:     hostname,
+		PodName:      settings.PodName,
+		PodNamespace: settings.PodNamespace,
+		NodeName:     settings.NodeName,
+		Version:      version,
+		GitCommit:    gitCommit,
+		BuildDate:    buildDate,
+	}
+
+	pb.RegisterServerServer(grpcServer, NewServer(injector, serverInfo))
+	healthServer := NewHealthServer(healthRegistry)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	serverStreamConfig := ServerStreamConfig{
+		Count:    settings.ServerStreamCount,
+		Interval: settings.ServerStreamInterval,
+		Jitter:   settings.ServerStreamJitter,
+	}
+	if err := serverStreamConfig.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid server stream settings")
+	}
+	pb.RegisterStreamingServerServer(grpcServer, NewStreamingServer(serverStreamConfig))
+
+	if settings.GRPCAdmin {
+		log.Info().Msg("registering gRPC admin service")
+		pb.RegisterAdminServer(grpcServer, NewAdminServer(healthRegistry))
+	}
+
 	reflection.Register(grpcServer)
-	grpcServer.Serve(listener)
+
+	go func() {
+		log.Info().Msg("starting metrics server")
+		if err := metrics.Serve("0.0.0.0:" + settings.MetricsPort); err != nil {
+			log.Fatal().Err(err).Msg("failed to serve metrics")
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Fatal().Err(err).Msg("failed to serve")
+		}
+	}()
+
+	var webServer *http.Server
+	if settings.GRPCWeb {
+		log.Info().
+			Str("port", settings.GRPCWebPort).
+			Strs("allowed_origins", settings.GRPCWebAllowedOrigins).
+			Msg("starting gRPC-Web server")
+		webServer = &http.Server{
+			Addr: "0.0.0.0:" + settings.GRPCWebPort,
+			Handler: grpcweb.NewHandler(grpcServer, grpcweb.Config{
+				AllowedOrigins: settings.GRPCWebAllowedOrigins,
+				AllowedHeaders: settings.GRPCWebAllowedHeaders,
+			}),
+			TLSConfig: tlsConfig,
+		}
+
+		go func() {
+			var err error
+			if tlsConfig != nil {
+				err = webServer.ListenAndServeTLS("", "")
+			} else {
+				err = webServer.ListenAndServe()
+			}
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatal().Err(err).Msg("failed to serve gRPC-Web")
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	stop()
+
+	log.Info().
+		Dur("drain_period", settings.ShutdownDrainPeriod).
+		Msg("shutting down: reporting NOT_SERVING")
+	healthRegistry.Shutdown()
+	time.Sleep(settings.ShutdownDrainPeriod)
+
+	log.Info().Msg("shutting down: gracefully stopping gRPC server")
+	healthServer.Stop()
+
+	// One deadline covers both servers, so shutdown never takes longer than
+	// ShutdownTimeout after the drain period.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), settings.ShutdownTimeout)
+	defer cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	if webServer != nil {
+		if err := webServer.Shutdown(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("shutting down: timed out waiting for gRPC-Web requests")
+		}
+	}
+
+	select {
+	case <-stopped:
+		log.Info().Msg("shutting down: gRPC server stopped")
+	case <-shutdownCtx.Done():
+		log.Warn().Msg("shutting down: timed out waiting for RPCs, forcing stop")
+		grpcServer.Stop()
+	}
 }
@@ -0,0 +1,275 @@
+package behavior
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zufardhiyaulhaq/echo-grpc/pkg/payload"
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/fault"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys callers use to steer a single call.
+const (
+	MetadataDelay               = "x-echo-delay"
+	MetadataStatus              = "x-echo-status"
+	MetadataStatusMessage       = "x-echo-status-message"
+	MetadataResponseSize        = "x-echo-response-size"
+	MetadataResponseCompression = "x-echo-response-compression"
+	MetadataTrailerPrefix       = "x-echo-trailer-"
+)
+
+// Behavior is what a caller asked for through metadata.
+type Behavior struct {
+	// Delay is applied before every response message.
+	Delay time.Duration
+	// Status ends the call once the handler has finished, nil keeps the
+	// handler's own result.
+	Status *status.Status
+	// ResponseSize pads or truncates the response text to this many bytes,
+	// at most payload.MaxSize, a negative value leaves it untouched.
+	ResponseSize int
+	// Trailer is sent with the call's trailers, keys have MetadataTrailerPrefix
+	// stripped.
+	Trailer metadata.MD
+	// Payload replaces the payload of every response message, nil keeps the
+	// echoed one.
+	Payload *payload.Spec
+	// Compression is the compressor of the responses, empty keeps grpc's
+	// choice.
+	Compression string
+}
+
+func FromIncomingContext(ctx context.Context) (Behavior, error) {
+	behavior := Behavior{
+		ResponseSize: -1,
+		Trailer:      metadata.MD{},
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return behavior, nil
+	}
+
+	if value := first(md, MetadataDelay); value != "" {
+		delay, err := time.ParseDuration(value)
+		if err != nil || delay < 0 {
+			return behavior, fmt.Errorf("invalid %s: %q", MetadataDelay, value)
+		}
+		behavior.Delay = delay
+	}
+
+	if value := first(md, MetadataStatus); value != "" {
+		code, err := fault.ParseCode(value)
+		if err != nil {
+			return behavior, fmt.Errorf("invalid %s: %w", MetadataStatus, err)
+		}
+		behavior.Status = status.New(code, first(md, MetadataStatusMessage))
+	}
+
+	if value := first(md, MetadataResponseSize); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return behavior, fmt.Errorf("invalid %s: %q", MetadataResponseSize, value)
+		}
+		if size < 0 || size > payload.MaxSize {
+			return behavior, fmt.Errorf("%s must be between 0 and %d, got %d", MetadataResponseSize, payload.MaxSize, size)
+		}
+		behavior.ResponseSize = size
+	}
+
+	behavior.Compression = first(md, MetadataResponseCompression)
+
+	spec, ok, err := payload.FromMetadata(md)
+	if err != nil {
+		return behavior, err
+	}
+	if ok {
+		behavior.Payload = &spec
+	}
+
+	for key, values := range md {
+		if name := strings.TrimPrefix(key, MetadataTrailerPrefix); name != key && name != "" {
+			behavior.Trailer.Append(name, values...)
+		}
+	}
+
+	return behavior, nil
+}
+
+// Wait blocks for the configured delay or until ctx is done.
+func (b Behavior) Wait(ctx context.Context) error {
+	if b.Delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(b.Delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// Resize applies ResponseSize and Payload to the echo responses of this
+// server.
+func (b Behavior) Resize(message interface{}) {
+	if b.ResponseSize >= 0 {
+		switch response := message.(type) {
+		case *pb.Response:
+			response.Response = resize(response.Response, b.ResponseSize)
+		case *pb.StreamResponse:
+			response.Response = resize(response.Response, b.ResponseSize)
+		}
+	}
+
+	if b.Payload != nil {
+		switch response := message.(type) {
+		case *pb.Response:
+			response.Payload = b.Payload.Generate()
+		case *pb.StreamResponse:
+			response.Payload = b.Payload.Generate()
+		}
+	}
+}
+
+// Compress switches the responses of the call in ctx to the requested
+// compressor.
+func (b Behavior) Compress(ctx context.Context) error {
+	if b.Compression == "" {
+		return nil
+	}
+
+	if err := grpc.SetSendCompressor(ctx, b.Compression); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid %s: %v", MetadataResponseCompression, err)
+	}
+	return nil
+}
+
+// Result returns the error the call must end with given the handler's own
+// error.
+func (b Behavior) Result(err error) error {
+	if err != nil || b.Status == nil {
+		return err
+	}
+	return b.Status.Err()
+}
+
+// UnaryServerInterceptor applies the caller's behavior to unary calls of the
+// given services.
+func UnaryServerInterceptor(services ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !matches(info.FullMethod, services) {
+			return handler(ctx, req)
+		}
+
+		behavior, err := FromIncomingContext(ctx)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		if len(behavior.Trailer) > 0 {
+			grpc.SetTrailer(ctx, behavior.Trailer)
+		}
+
+		if err := behavior.Compress(ctx); err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, req)
+		if waitErr := behavior.Wait(ctx); waitErr != nil {
+			return nil, waitErr
+		}
+
+		if err := behavior.Result(err); err != nil {
+			return nil, err
+		}
+
+		behavior.Resize(resp)
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor applies the caller's behavior to streaming calls of
+// the given services.
+func StreamServerInterceptor(services ...string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !matches(info.FullMethod, services) {
+			return handler(srv, ss)
+		}
+
+		behavior, err := FromIncomingContext(ss.Context())
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		if len(behavior.Trailer) > 0 {
+			ss.SetTrailer(behavior.Trailer)
+		}
+
+		if err := behavior.Compress(ss.Context()); err != nil {
+			return err
+		}
+
+		err = handler(srv, &serverStream{ServerStream: ss, behavior: behavior})
+		return behavior.Result(err)
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	behavior Behavior
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	if err := s.behavior.Wait(s.Context()); err != nil {
+		return err
+	}
+
+	s.behavior.Resize(m)
+	return s.ServerStream.SendMsg(m)
+}
+
+func matches(fullMethod string, services []string) bool {
+	for _, service := range services {
+		if strings.HasPrefix(fullMethod, "/"+service+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// resize pads value with dots or truncates it to size bytes. A truncated
+// value is cut before any rune split by size, so it may be shorter but stays
+// valid UTF-8 as proto3 strings must.
+func resize(value string, size int) string {
+	switch {
+	case size == len(value):
+		return value
+	case size < len(value):
+		for size > 0 && !utf8.RuneStart(value[size]) {
+			size--
+		}
+		return value[:size]
+	default:
+		return value + strings.Repeat(".", size-len(value))
+	}
+}
@@ -0,0 +1,138 @@
+package behavior
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zufardhiyaulhaq/echo-grpc/pkg/payload"
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		size  int
+		want  string
+	}{
+		{name: "same size", value: "from server: hi", size: 15, want: "from server: hi"},
+		{name: "empty to zero", value: "", size: 0, want: ""},
+		{name: "zero", value: "hello", size: 0, want: ""},
+		{name: "truncate", value: "hello", size: 3, want: "hel"},
+		{name: "pad", value: "hi", size: 5, want: "hi..."},
+		{name: "pad empty", value: "", size: 3, want: "..."},
+		{name: "cut inside a rune", value: "aé", size: 2, want: "a"},
+		{name: "cut inside a four byte rune", value: "a😀b", size: 4, want: "a"},
+		{name: "cut after a rune", value: "aéb", size: 3, want: "aé"},
+		{name: "multibyte same size", value: "é😀", size: 6, want: "é😀"},
+		{name: "pad multibyte", value: "é", size: 4, want: "é.."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resize(tt.value, tt.size)
+			if got != tt.want {
+				t.Errorf("resize(%q, %d) = %q, want %q", tt.value, tt.size, got, tt.want)
+			}
+			if len(got) > tt.size {
+				t.Errorf("resize(%q, %d) is %d bytes", tt.value, tt.size, len(got))
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("resize(%q, %d) = %q, want valid UTF-8", tt.value, tt.size, got)
+			}
+		})
+	}
+}
+
+func TestFromIncomingContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		md      metadata.MD
+		check   func(*testing.T, Behavior)
+		wantErr bool
+	}{
+		{
+			name: "no metadata",
+			md:   metadata.MD{},
+			check: func(t *testing.T, b Behavior) {
+				if b.ResponseSize != -1 || b.Delay != 0 || b.Status != nil || b.Payload != nil {
+					t.Errorf("behavior = %+v, want defaults", b)
+				}
+			},
+		},
+		{
+			name: "delay, status and trailers",
+			md: metadata.Pairs(
+				MetadataDelay, "250ms",
+				MetadataStatus, "UNAVAILABLE",
+				MetadataStatusMessage, "try later",
+				MetadataTrailerPrefix+"region", "eu",
+			),
+			check: func(t *testing.T, b Behavior) {
+				if b.Delay != 250*time.Millisecond {
+					t.Errorf("delay = %s, want 250ms", b.Delay)
+				}
+				if b.Status.Code() != codes.Unavailable || b.Status.Message() != "try later" {
+					t.Errorf("status = %v, want UNAVAILABLE try later", b.Status)
+				}
+				if got := b.Trailer.Get("region"); len(got) != 1 || got[0] != "eu" {
+					t.Errorf("trailer region = %v, want [eu]", got)
+				}
+			},
+		},
+		{
+			name: "response size",
+			md:   metadata.Pairs(MetadataResponseSize, "14"),
+			check: func(t *testing.T, b Behavior) {
+				if b.ResponseSize != 14 {
+					t.Errorf("response size = %d, want 14", b.ResponseSize)
+				}
+			},
+		},
+		{
+			name: "max response size",
+			md:   metadata.Pairs(MetadataResponseSize, strconv.Itoa(payload.MaxSize)),
+			check: func(t *testing.T, b Behavior) {
+				if b.ResponseSize != payload.MaxSize {
+					t.Errorf("response size = %d, want %d", b.ResponseSize, payload.MaxSize)
+				}
+			},
+		},
+		{name: "negative delay", md: metadata.Pairs(MetadataDelay, "-1s"), wantErr: true},
+		{name: "invalid delay", md: metadata.Pairs(MetadataDelay, "soon"), wantErr: true},
+		{name: "unknown status", md: metadata.Pairs(MetadataStatus, "BROKEN"), wantErr: true},
+		{name: "invalid response size", md: metadata.Pairs(MetadataResponseSize, "big"), wantErr: true},
+		{name: "negative response size", md: metadata.Pairs(MetadataResponseSize, "-1"), wantErr: true},
+		{name: "response size above max", md: metadata.Pairs(MetadataResponseSize, strconv.Itoa(payload.MaxSize+1)), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromIncomingContext(metadata.NewIncomingContext(context.Background(), tt.md))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("FromIncomingContext() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromIncomingContext() failed: %v", err)
+			}
+			tt.check(t, got)
+		})
+	}
+}
+
+func TestResizeResponseOfExactSize(t *testing.T) {
+	response := &pb.Response{Response: "from server: x"}
+	Behavior{ResponseSize: len(response.Response)}.Resize(response)
+
+	if response.Response != "from server: x" {
+		t.Errorf("response = %q, want it unchanged", response.Response)
+	}
+}
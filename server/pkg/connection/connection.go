@@ -0,0 +1,144 @@
+// Package connection observes the HTTP/2 connections of the gRPC server.
+// grpc-go does not report the GOAWAY frames it sends, so the frames written
+// to each connection are parsed, above TLS, to count and log them.
+package connection
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/metrics"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc/credentials"
+)
+
+// Reasons grpc-go puts in the debug data of the GOAWAY frames it sends, any
+// other debug data is reported as "other" to bound the metric labels.
+var reasons = map[string]bool{
+	"max_idle":       true,
+	"max_age":        true,
+	"too_many_pings": true,
+	"graceful_stop":  true,
+}
+
+// maxDebugData bounds how much GOAWAY debug data is kept for logging.
+const maxDebugData = 256
+
+// Credentials wraps creds so every connection they hand to the gRPC server is
+// tracked.
+func Credentials(creds credentials.TransportCredentials) credentials.TransportCredentials {
+	return &trackingCredentials{TransportCredentials: creds}
+}
+
+type trackingCredentials struct {
+	credentials.TransportCredentials
+}
+
+func (c *trackingCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, authInfo, err := c.TransportCredentials.ServerHandshake(rawConn)
+	if err != nil {
+		return conn, authInfo, err
+	}
+
+	return newConn(conn), authInfo, nil
+}
+
+func (c *trackingCredentials) Clone() credentials.TransportCredentials {
+	return &trackingCredentials{TransportCredentials: c.TransportCredentials.Clone()}
+}
+
+type conn struct {
+	net.Conn
+	frames    frameReader
+	closed    func()
+	closeOnce sync.Once
+}
+
+func newConn(c net.Conn) *conn {
+	tracked := &conn{
+		Conn:   c,
+		closed: metrics.ConnectionOpened(),
+	}
+	tracked.frames.onGoAway = tracked.goAway
+
+	return tracked
+}
+
+// Write is only called by the single writer goroutine of the HTTP/2
+// transport, so frames needs no locking.
+func (c *conn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.frames.feed(p[:n])
+	return n, err
+}
+
+func (c *conn) Close() error {
+	c.closeOnce.Do(c.closed)
+	return c.Conn.Close()
+}
+
+func (c *conn) goAway(lastStreamID uint32, code http2.ErrCode, debugData []byte) {
+	reason := string(debugData)
+	label := reason
+	if !reasons[label] {
+		label = "other"
+	}
+
+	metrics.GoAwaySent(code.String(), label)
+	log.Info().
+		Str("remote_addr", c.RemoteAddr().String()).
+		Str("code", code.String()).
+		Str("reason", reason).
+		Uint32("last_stream_id", lastStreamID).
+		Msg("sent GOAWAY")
+}
+
+// frameReader follows the frame boundaries of an HTTP/2 byte stream written
+// in arbitrary chunks and collects the payload of GOAWAY frames.
+type frameReader struct {
+	header  [9]byte
+	read    int
+	left    int
+	payload []byte
+	goAway  bool
+
+	onGoAway func(lastStreamID uint32, code http2.ErrCode, debugData []byte)
+}
+
+func (f *frameReader) feed(p []byte) {
+	for len(p) > 0 {
+		if f.read < len(f.header) {
+			n := copy(f.header[f.read:], p)
+			f.read += n
+			p = p[n:]
+			if f.read < len(f.header) {
+				return
+			}
+
+			f.left = int(f.header[0])<<16 | int(f.header[1])<<8 | int(f.header[2])
+			f.goAway = http2.FrameType(f.header[3]) == http2.FrameGoAway
+			f.payload = f.payload[:0]
+		}
+
+		n := min(len(p), f.left)
+		if f.goAway {
+			keep := min(n, 8+maxDebugData-len(f.payload))
+			f.payload = append(f.payload, p[:keep]...)
+		}
+		f.left -= n
+		p = p[n:]
+
+		if f.left == 0 {
+			if f.goAway && len(f.payload) >= 8 {
+				f.onGoAway(
+					binary.BigEndian.Uint32(f.payload[0:4])&(1<<31-1),
+					http2.ErrCode(binary.BigEndian.Uint32(f.payload[4:8])),
+					f.payload[8:],
+				)
+			}
+			f.read = 0
+		}
+	}
+}
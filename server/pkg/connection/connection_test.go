@@ -0,0 +1,65 @@
+package connection
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/http2"
+)
+
+type goAwayFrame struct {
+	lastStreamID uint32
+	code         http2.ErrCode
+	debugData    string
+}
+
+func TestFrameReader(t *testing.T) {
+	longDebugData := strings.Repeat("x", 2*maxDebugData)
+
+	var stream bytes.Buffer
+	framer := http2.NewFramer(&stream, nil)
+	framer.WriteSettings(http2.Setting{ID: http2.SettingMaxFrameSize, Val: 1 << 20})
+	framer.WriteData(1, false, []byte("hello"))
+	framer.WriteGoAway(1<<31-1, http2.ErrCodeNo, []byte("graceful_stop"))
+	framer.WritePing(false, [8]byte{1, 2, 3})
+	framer.WriteData(1, true, bytes.Repeat([]byte{byte(http2.FrameGoAway)}, 100))
+	framer.WriteGoAway(7, http2.ErrCodeEnhanceYourCalm, []byte("too_many_pings"))
+	framer.WriteGoAway(9, http2.ErrCodeProtocol, nil)
+	framer.WriteGoAway(11, http2.ErrCodeNo, []byte(longDebugData))
+	framer.WriteRSTStream(3, http2.ErrCodeCancel)
+
+	want := []goAwayFrame{
+		{lastStreamID: 1<<31 - 1, code: http2.ErrCodeNo, debugData: "graceful_stop"},
+		{lastStreamID: 7, code: http2.ErrCodeEnhanceYourCalm, debugData: "too_many_pings"},
+		{lastStreamID: 9, code: http2.ErrCodeProtocol, debugData: ""},
+		{lastStreamID: 11, code: http2.ErrCodeNo, debugData: longDebugData[:maxDebugData]},
+	}
+
+	// The transport writes frames in arbitrary chunks, which may split the
+	// frame headers.
+	for _, chunkSize := range []int{1, 2, 5, 9, 10, 64, stream.Len()} {
+		var got []goAwayFrame
+		reader := frameReader{
+			onGoAway: func(lastStreamID uint32, code http2.ErrCode, debugData []byte) {
+				got = append(got, goAwayFrame{lastStreamID, code, string(debugData)})
+			},
+		}
+
+		data := stream.Bytes()
+		for len(data) > 0 {
+			n := min(chunkSize, len(data))
+			reader.feed(data[:n])
+			data = data[n:]
+		}
+
+		if len(got) != len(want) {
+			t.Fatalf("chunks of %d bytes: got %d GOAWAY frames %+v, want %d", chunkSize, len(got), got, len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("chunks of %d bytes: frame %d = %+v, want %+v", chunkSize, i, got[i], want[i])
+			}
+		}
+	}
+}
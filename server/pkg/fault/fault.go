@@ -0,0 +1,257 @@
+package fault
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DistributionFixed       = "fixed"
+	DistributionUniform     = "uniform"
+	DistributionNormal      = "normal"
+	DistributionExponential = "exponential"
+)
+
+// Metadata keys that override the configured faults for a single call.
+const (
+	MetadataAbortPercent      = "x-echo-fault-abort-percent"
+	MetadataAbortCode         = "x-echo-fault-abort-code"
+	MetadataAbortMessage      = "x-echo-fault-abort-message"
+	MetadataDelayPercent      = "x-echo-fault-delay-percent"
+	MetadataDelayDistribution = "x-echo-fault-delay-distribution"
+	MetadataDelay             = "x-echo-fault-delay"
+	MetadataDelayMin          = "x-echo-fault-delay-min"
+	MetadataDelayMax          = "x-echo-fault-delay-max"
+	MetadataDelayStddev       = "x-echo-fault-delay-stddev"
+	MetadataDropPercent       = "x-echo-fault-drop-percent"
+)
+
+// Config describes which faults are injected and how often. Percentages are
+// in the range 0-100.
+type Config struct {
+	AbortPercent float64
+	AbortCode    codes.Code
+	AbortMessage string
+
+	DelayPercent      float64
+	DelayDistribution string
+	// Delay is the fixed delay, or the mean of the normal and exponential
+	// distributions.
+	Delay       time.Duration
+	DelayMin    time.Duration
+	DelayMax    time.Duration
+	DelayStddev time.Duration
+
+	// DropPercent of calls never get an answer and hang until the caller's
+	// deadline expires or the call is cancelled.
+	DropPercent float64
+}
+
+func (c Config) Validate() error {
+	for name, percent := range map[string]float64{
+		"abort percent": c.AbortPercent,
+		"delay percent": c.DelayPercent,
+		"drop percent":  c.DropPercent,
+	} {
+		if percent < 0 || percent > 100 {
+			return fmt.Errorf("%s must be between 0 and 100, got %v", name, percent)
+		}
+	}
+
+	for name, delay := range map[string]time.Duration{
+		"delay":        c.Delay,
+		"delay min":    c.DelayMin,
+		"delay max":    c.DelayMax,
+		"delay stddev": c.DelayStddev,
+	} {
+		if delay < 0 {
+			return fmt.Errorf("%s must not be negative, got %s", name, delay)
+		}
+	}
+
+	switch c.DelayDistribution {
+	case DistributionFixed, DistributionNormal, DistributionExponential:
+	case DistributionUniform:
+		if c.DelayMax < c.DelayMin {
+			return fmt.Errorf("delay max %s is lower than delay min %s", c.DelayMax, c.DelayMin)
+		}
+	default:
+		return fmt.Errorf("unknown delay distribution %q", c.DelayDistribution)
+	}
+
+	if c.AbortPercent > 0 && c.AbortCode == codes.OK {
+		return fmt.Errorf("abort code must not be OK")
+	}
+
+	return nil
+}
+
+type Injector struct {
+	config Config
+}
+
+func NewInjector(config Config) (*Injector, error) {
+	config.DelayDistribution = normalizeDistribution(config.DelayDistribution)
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Injector{
+		config: config,
+	}, nil
+}
+
+// Inject applies the configured faults, overridden by any fault metadata on
+// the incoming call, before a handler runs. A non-nil error must be returned
+// to the caller as is.
+func (i *Injector) Inject(ctx context.Context) error {
+	config, err := i.configFor(ctx)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if hit(config.DropPercent) {
+		<-ctx.Done()
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	if hit(config.DelayPercent) {
+		timer := time.NewTimer(config.delay())
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	if hit(config.AbortPercent) {
+		return status.Error(config.AbortCode, config.AbortMessage)
+	}
+
+	return nil
+}
+
+func (i *Injector) configFor(ctx context.Context) (Config, error) {
+	config := i.config
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return config, nil
+	}
+
+	overridden := false
+	value := func(key string) (string, bool) {
+		values := md.Get(key)
+		if len(values) == 0 {
+			return "", false
+		}
+		overridden = true
+		return values[0], true
+	}
+
+	var err error
+	if v, ok := value(MetadataAbortPercent); ok {
+		if config.AbortPercent, err = strconv.ParseFloat(v, 64); err != nil {
+			return config, fmt.Errorf("invalid %s: %w", MetadataAbortPercent, err)
+		}
+	}
+	if v, ok := value(MetadataAbortCode); ok {
+		if config.AbortCode, err = ParseCode(v); err != nil {
+			return config, fmt.Errorf("invalid %s: %w", MetadataAbortCode, err)
+		}
+	}
+	if v, ok := value(MetadataAbortMessage); ok {
+		config.AbortMessage = v
+	}
+	if v, ok := value(MetadataDelayPercent); ok {
+		if config.DelayPercent, err = strconv.ParseFloat(v, 64); err != nil {
+			return config, fmt.Errorf("invalid %s: %w", MetadataDelayPercent, err)
+		}
+	}
+	if v, ok := value(MetadataDelayDistribution); ok {
+		config.DelayDistribution = normalizeDistribution(v)
+	}
+	for key, target := range map[string]*time.Duration{
+		MetadataDelay:       &config.Delay,
+		MetadataDelayMin:    &config.DelayMin,
+		MetadataDelayMax:    &config.DelayMax,
+		MetadataDelayStddev: &config.DelayStddev,
+	} {
+		if v, ok := value(key); ok {
+			if *target, err = time.ParseDuration(v); err != nil {
+				return config, fmt.Errorf("invalid %s: %w", key, err)
+			}
+		}
+	}
+	if v, ok := value(MetadataDropPercent); ok {
+		if config.DropPercent, err = strconv.ParseFloat(v, 64); err != nil {
+			return config, fmt.Errorf("invalid %s: %w", MetadataDropPercent, err)
+		}
+	}
+
+	if !overridden {
+		return config, nil
+	}
+
+	return config, config.Validate()
+}
+
+func (c Config) delay() time.Duration {
+	var delay time.Duration
+
+	switch c.DelayDistribution {
+	case DistributionUniform:
+		delay = c.DelayMin
+		if c.DelayMax > c.DelayMin {
+			delay += time.Duration(rand.Int63n(int64(c.DelayMax - c.DelayMin)))
+		}
+	case DistributionNormal:
+		delay = c.Delay + time.Duration(rand.NormFloat64()*float64(c.DelayStddev))
+	case DistributionExponential:
+		delay = time.Duration(rand.ExpFloat64() * float64(c.Delay))
+	default:
+		delay = c.Delay
+	}
+
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// normalizeDistribution accepts distribution names in any case, from the
+// settings and the metadata alike.
+func normalizeDistribution(distribution string) string {
+	return strings.ToLower(strings.TrimSpace(distribution))
+}
+
+func hit(percent float64) bool {
+	return percent > 0 && rand.Float64()*100 < percent
+}
+
+// ParseCode accepts a gRPC status code either by name (UNAVAILABLE,
+// unavailable) or by number (14).
+func ParseCode(value string) (codes.Code, error) {
+	if number, err := strconv.ParseUint(value, 10, 32); err == nil {
+		if number > uint64(codes.Unauthenticated) {
+			return codes.OK, fmt.Errorf("unknown code %d", number)
+		}
+		return codes.Code(number), nil
+	}
+
+	var code codes.Code
+	if err := code.UnmarshalJSON([]byte(`"` + strings.ToUpper(value) + `"`)); err != nil {
+		return codes.OK, err
+	}
+	return code, nil
+}
@@ -0,0 +1,228 @@
+package fault
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseCode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    codes.Code
+		wantErr bool
+	}{
+		{value: "UNAVAILABLE", want: codes.Unavailable},
+		{value: "unavailable", want: codes.Unavailable},
+		{value: "14", want: codes.Unavailable},
+		{value: "0", want: codes.OK},
+		{value: "16", want: codes.Unauthenticated},
+		{value: "17", wantErr: true},
+		{value: "BROKEN", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseCode(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseCode(%q) = %s, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCode(%q) failed: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseCode(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{name: "no faults", config: Config{DelayDistribution: DistributionFixed}},
+		{
+			name:   "abort",
+			config: Config{AbortPercent: 100, AbortCode: codes.Unavailable, DelayDistribution: DistributionFixed},
+		},
+		{
+			name:    "abort with OK",
+			config:  Config{AbortPercent: 10, DelayDistribution: DistributionFixed},
+			wantErr: "abort code",
+		},
+		{
+			name:    "percent above 100",
+			config:  Config{DropPercent: 101, DelayDistribution: DistributionFixed},
+			wantErr: "drop percent",
+		},
+		{
+			name:    "negative percent",
+			config:  Config{DelayPercent: -1, DelayDistribution: DistributionFixed},
+			wantErr: "delay percent",
+		},
+		{
+			name:    "negative stddev",
+			config:  Config{DelayStddev: -time.Second, DelayDistribution: DistributionNormal},
+			wantErr: "delay stddev",
+		},
+		{
+			name:   "uniform",
+			config: Config{DelayMin: time.Second, DelayMax: 2 * time.Second, DelayDistribution: DistributionUniform},
+		},
+		{
+			name:    "uniform max below min",
+			config:  Config{DelayMin: 2 * time.Second, DelayMax: time.Second, DelayDistribution: DistributionUniform},
+			wantErr: "delay max",
+		},
+		{
+			name:    "unknown distribution",
+			config:  Config{DelayDistribution: "poisson"},
+			wantErr: "unknown delay distribution",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewInjectorNormalizesDistribution(t *testing.T) {
+	if _, err := NewInjector(Config{DelayDistribution: " Normal "}); err != nil {
+		t.Fatalf("NewInjector() failed: %v", err)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		min, max time.Duration
+	}{
+		{
+			name:   "fixed",
+			config: Config{DelayDistribution: DistributionFixed, Delay: time.Second},
+			min:    time.Second, max: time.Second,
+		},
+		{
+			name:   "uniform",
+			config: Config{DelayDistribution: DistributionUniform, DelayMin: time.Second, DelayMax: 2 * time.Second},
+			min:    time.Second, max: 2 * time.Second,
+		},
+		{
+			name:   "uniform without range",
+			config: Config{DelayDistribution: DistributionUniform, DelayMin: time.Second, DelayMax: time.Second},
+			min:    time.Second, max: time.Second,
+		},
+		{
+			name:   "normal without stddev",
+			config: Config{DelayDistribution: DistributionNormal, Delay: time.Second},
+			min:    time.Second, max: time.Second,
+		},
+		{
+			// Never negative, however far below the mean a sample falls.
+			name:   "normal",
+			config: Config{DelayDistribution: DistributionNormal, Delay: time.Millisecond, DelayStddev: time.Hour},
+			min:    0, max: 1000 * time.Hour,
+		},
+		{
+			name:   "exponential",
+			config: Config{DelayDistribution: DistributionExponential, Delay: time.Second},
+			min:    0, max: 1000 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				if got := tt.config.delay(); got < tt.min || got > tt.max {
+					t.Fatalf("delay() = %s, want between %s and %s", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestInject(t *testing.T) {
+	injector, err := NewInjector(Config{DelayDistribution: DistributionFixed})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		md       metadata.MD
+		wantCode codes.Code
+	}{
+		{name: "no metadata", md: metadata.MD{}, wantCode: codes.OK},
+		{
+			name:     "abort",
+			md:       metadata.Pairs(MetadataAbortPercent, "100", MetadataAbortCode, "unavailable", MetadataAbortMessage, "down"),
+			wantCode: codes.Unavailable,
+		},
+		{
+			name:     "drop until the deadline",
+			md:       metadata.Pairs(MetadataDropPercent, "100"),
+			wantCode: codes.DeadlineExceeded,
+		},
+		{
+			name:     "delay longer than the deadline",
+			md:       metadata.Pairs(MetadataDelayPercent, "100", MetadataDelay, "1h"),
+			wantCode: codes.DeadlineExceeded,
+		},
+		{
+			name:     "abort with OK",
+			md:       metadata.Pairs(MetadataAbortPercent, "100"),
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "invalid percent",
+			md:       metadata.Pairs(MetadataDropPercent, "often"),
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "invalid delay",
+			md:       metadata.Pairs(MetadataDelayPercent, "100", MetadataDelay, "soon"),
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "negative stddev",
+			md:       metadata.Pairs(MetadataDelayDistribution, "NORMAL", MetadataDelayStddev, "-1s"),
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			err := injector.Inject(metadata.NewIncomingContext(ctx, tt.md))
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("Inject() = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+// Package grpcweb serves gRPC-Web, as described in
+// https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-WEB.md, by
+// translating each call to a native gRPC request for grpc.Server.ServeHTTP.
+// Calls go through the same interceptors as native ones.
+package grpcweb
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	contentTypeBinary = "application/grpc-web"
+	contentTypeText   = "application/grpc-web-text"
+
+	// trailerFlag marks the frame holding the trailers at the end of a
+	// gRPC-Web response body.
+	trailerFlag = 0x80
+)
+
+// defaultHeaders are the request headers gRPC-Web clients send, always
+// allowed on top of Config.AllowedHeaders.
+var defaultHeaders = []string{"Content-Type", "Grpc-Timeout", "X-Grpc-Web", "X-User-Agent"}
+
+// Config holds the CORS policy browsers are checked against.
+type Config struct {
+	// AllowedOrigins lists the origins allowed to call the server, "*"
+	// allows any origin.
+	AllowedOrigins []string
+	// AllowedHeaders lists the request headers a browser may send on top of
+	// the gRPC-Web ones, "*" allows any header.
+	AllowedHeaders []string
+}
+
+// NewHandler serves gRPC-Web, in binary (application/grpc-web) and text
+// (application/grpc-web-text) mode, and CORS preflights for the services
+// registered on server. Browsers can only make unary and server streaming
+// calls over gRPC-Web. Other requests are passed to server unchanged.
+func NewHandler(server *grpc.Server, config Config) http.Handler {
+	return &handler{
+		server:        server,
+		allowedOrigin: originFunc(config.AllowedOrigins),
+		allowedHeader: headerFunc(config.AllowedHeaders),
+	}
+}
+
+type handler struct {
+	server        *grpc.Server
+	allowedOrigin func(origin string) bool
+	allowedHeader func(header string) bool
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "":
+		h.preflight(w, req)
+	case req.Method == http.MethodPost && isGRPCWeb(req.Header.Get("Content-Type")):
+		h.call(w, req)
+	default:
+		h.server.ServeHTTP(w, req)
+	}
+}
+
+// preflight answers a CORS preflight. A disallowed origin, method or header
+// gets no CORS headers, which makes the browser refuse the call.
+func (h *handler) preflight(w http.ResponseWriter, req *http.Request) {
+	w.Header().Add("Vary", "Origin")
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	origin := req.Header.Get("Origin")
+	if origin == "" || !h.allowedOrigin(origin) || req.Header.Get("Access-Control-Request-Method") != http.MethodPost {
+		return
+	}
+
+	var headers []string
+	for _, value := range req.Header.Values("Access-Control-Request-Headers") {
+		for _, header := range strings.Split(value, ",") {
+			if header = http.CanonicalHeaderKey(strings.TrimSpace(header)); header != "" {
+				headers = append(headers, header)
+			}
+		}
+	}
+	for _, header := range headers {
+		if !h.allowedHeader(header) {
+			return
+		}
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Credentials", "true")
+	w.Header().Set("Access-Control-Allow-Methods", http.MethodPost)
+	if len(headers) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	w.Header().Set("Access-Control-Max-Age", "600")
+}
+
+// call forwards a gRPC-Web request to the gRPC server as an HTTP/2 gRPC
+// request and rewrites the response, moving the trailers into the body.
+func (h *handler) call(w http.ResponseWriter, req *http.Request) {
+	contentType := req.Header.Get("Content-Type")
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = strings.TrimSpace(contentType[:i])
+	}
+	text := strings.HasPrefix(contentType, contentTypeText)
+
+	var subtype string
+	if i := strings.IndexByte(contentType, '+'); i >= 0 {
+		subtype = contentType[i:]
+	}
+
+	grpcReq := req.Clone(req.Context())
+	grpcReq.Proto = "HTTP/2.0"
+	grpcReq.ProtoMajor, grpcReq.ProtoMinor = 2, 0
+	grpcReq.Header.Set("Content-Type", "application/grpc"+subtype)
+	grpcReq.Header.Del("Content-Length")
+	grpcReq.ContentLength = -1
+	if text {
+		grpcReq.Body = io.NopCloser(base64.NewDecoder(base64.StdEncoding, req.Body))
+	}
+
+	if origin := req.Header.Get("Origin"); origin != "" && h.allowedOrigin(origin) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+	w.Header().Add("Vary", "Origin")
+
+	response := newResponseWriter(w, contentType, text)
+	h.server.ServeHTTP(response, grpcReq)
+	response.finish()
+}
+
+// responseWriter is handed to grpc.Server.ServeHTTP. It holds the headers
+// back until the first message, so a call that fails before sending one gets
+// a trailers-only response, and writes the trailers as the last frame of the
+// body.
+type responseWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	contentType string
+	code        int
+	// wroteHeader is set once the headers are sent to w.
+	wroteHeader bool
+
+	// text responses go through encoder, which is closed on every flush so
+	// each flushed chunk is padded base64 on its own.
+	text    bool
+	encoder io.WriteCloser
+}
+
+func newResponseWriter(w http.ResponseWriter, contentType string, text bool) *responseWriter {
+	return &responseWriter{
+		w:           w,
+		header:      make(http.Header),
+		contentType: contentType,
+		text:        text,
+	}
+}
+
+func (r *responseWriter) Header() http.Header {
+	return r.header
+}
+
+func (r *responseWriter) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+}
+
+func (r *responseWriter) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	r.sendHeader(false)
+
+	if r.code != http.StatusOK || !r.text {
+		return r.w.Write(p)
+	}
+	if r.encoder == nil {
+		r.encoder = base64.NewEncoder(base64.StdEncoding, r.w)
+	}
+	return r.encoder.Write(p)
+}
+
+// Flush is required by grpc.Server.ServeHTTP. Headers still held back stay
+// so until a message or the trailers are written.
+func (r *responseWriter) Flush() {
+	if !r.wroteHeader {
+		return
+	}
+	if r.encoder != nil {
+		r.encoder.Close()
+		r.encoder = nil
+	}
+	http.NewResponseController(r.w).Flush()
+}
+
+// finish writes the trailers once the call returned: in the headers when no
+// message was sent, in a trailer frame otherwise.
+func (r *responseWriter) finish() {
+	r.WriteHeader(http.StatusOK)
+	if !r.wroteHeader {
+		r.sendHeader(true)
+		return
+	}
+	if r.code != http.StatusOK {
+		return
+	}
+
+	var trailers strings.Builder
+	for _, name := range r.trailerNames() {
+		for _, value := range r.header.Values(r.trailerKey(name)) {
+			trailers.WriteString(strings.ToLower(name) + ": " + value + "\r\n")
+		}
+	}
+
+	frame := make([]byte, 5, 5+trailers.Len())
+	frame[0] = trailerFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(trailers.Len()))
+	frame = append(frame, trailers.String()...)
+
+	r.Write(frame)
+	r.Flush()
+}
+
+// sendHeader writes the held back headers to w, with the trailers when the
+// response is trailers-only.
+func (r *responseWriter) sendHeader(trailersOnly bool) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+
+	trailers := r.trailerNames()
+	isTrailer := make(map[string]bool, len(trailers))
+	for _, name := range trailers {
+		isTrailer[name] = true
+	}
+
+	header := r.w.Header()
+	exposed := map[string]bool{"Grpc-Status": true, "Grpc-Message": true}
+	for key, values := range r.header {
+		if key == "Trailer" || strings.HasPrefix(key, http.TrailerPrefix) || isTrailer[key] {
+			continue
+		}
+		header[key] = values
+		if len(values) > 0 {
+			exposed[key] = true
+		}
+	}
+	if trailersOnly {
+		for _, name := range trailers {
+			if values := r.header.Values(r.trailerKey(name)); len(values) > 0 {
+				header[name] = values
+				exposed[name] = true
+			}
+		}
+	}
+
+	if r.code == http.StatusOK {
+		names := make([]string, 0, len(exposed))
+		for name := range exposed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		header.Set("Content-Type", r.contentType)
+		header.Set("Access-Control-Expose-Headers", strings.Join(names, ", "))
+	}
+	r.w.WriteHeader(r.code)
+}
+
+// trailerNames returns the canonical names of the trailers set by the gRPC
+// server: the predeclared ones and those added with http.TrailerPrefix.
+func (r *responseWriter) trailerNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, value := range r.header.Values("Trailer") {
+		for _, name := range strings.Split(value, ",") {
+			add(name)
+		}
+	}
+	for key := range r.header {
+		if strings.HasPrefix(key, http.TrailerPrefix) {
+			add(strings.TrimPrefix(key, http.TrailerPrefix))
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// trailerKey returns the header map key holding the values of trailer name.
+func (r *responseWriter) trailerKey(name string) string {
+	if _, ok := r.header[http.TrailerPrefix+name]; ok {
+		return http.TrailerPrefix + name
+	}
+	return name
+}
+
+func isGRPCWeb(contentType string) bool {
+	return strings.HasPrefix(contentType, contentTypeBinary)
+}
+
+func originFunc(allowed []string) func(origin string) bool {
+	origins := make(map[string]bool, len(allowed))
+	for _, origin := range allowed {
+		origins[strings.TrimSpace(origin)] = true
+	}
+
+	return func(origin string) bool {
+		return origins["*"] || origins[origin]
+	}
+}
+
+func headerFunc(allowed []string) func(header string) bool {
+	headers := make(map[string]bool, len(allowed)+len(defaultHeaders))
+	for _, header := range defaultHeaders {
+		headers[header] = true
+	}
+	for _, header := range allowed {
+		headers[http.CanonicalHeaderKey(strings.TrimSpace(header))] = true
+	}
+
+	return func(header string) bool {
+		return headers["*"] || headers[http.CanonicalHeaderKey(header)]
+	}
+}
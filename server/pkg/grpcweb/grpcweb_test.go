@@ -0,0 +1,283 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestServer(t *testing.T, config Config) *httptest.Server {
+	t.Helper()
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("echo", healthpb.HealthCheckResponse_SERVING)
+
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
+	server := httptest.NewServer(NewHandler(grpcServer, config))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// frame returns msg as a length-prefixed gRPC message.
+func frame(t *testing.T, msg proto.Message) []byte {
+	t.Helper()
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := make([]byte, 5)
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(data)))
+	return append(prefix, data...)
+}
+
+// readFrames splits a gRPC-Web response body into its messages and trailers.
+func readFrames(t *testing.T, body []byte) (messages [][]byte, trailers string) {
+	t.Helper()
+
+	for len(body) > 0 {
+		if len(body) < 5 {
+			t.Fatalf("truncated frame header %x", body)
+		}
+		length := int(binary.BigEndian.Uint32(body[1:5]))
+		if len(body) < 5+length {
+			t.Fatalf("truncated frame of %d bytes", length)
+		}
+		if body[0]&trailerFlag != 0 {
+			trailers += string(body[5 : 5+length])
+		} else {
+			messages = append(messages, body[5:5+length])
+		}
+		body = body[5+length:]
+	}
+	return messages, trailers
+}
+
+func post(t *testing.T, url, contentType string, body []byte, header http.Header) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header = header.Clone()
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, data
+}
+
+func TestUnary(t *testing.T) {
+	server := newTestServer(t, Config{AllowedOrigins: []string{"http://allowed.test"}})
+	url := server.URL + "/grpc.health.v1.Health/Check"
+	request := frame(t, &healthpb.HealthCheckRequest{Service: "echo"})
+
+	tests := []struct {
+		name        string
+		contentType string
+		text        bool
+	}{
+		{name: "binary", contentType: "application/grpc-web"},
+		{name: "binary proto", contentType: "application/grpc-web+proto"},
+		{name: "text", contentType: "application/grpc-web-text", text: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := request
+			if tt.text {
+				body = []byte(base64.StdEncoding.EncodeToString(request))
+			}
+
+			resp, data := post(t, url, tt.contentType, body, http.Header{"Origin": {"http://allowed.test"}})
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+			if got := resp.Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "http://allowed.test" {
+				t.Errorf("Access-Control-Allow-Origin = %q, want the origin", got)
+			}
+			if got := resp.Header.Get("Access-Control-Expose-Headers"); !strings.Contains(got, "Grpc-Status") {
+				t.Errorf("Access-Control-Expose-Headers = %q, want Grpc-Status exposed", got)
+			}
+
+			if tt.text {
+				// Every flushed chunk is padded base64 on its own.
+				var decoded []byte
+				for _, chunk := range strings.SplitAfter(string(data), "=") {
+					chunk = strings.TrimLeft(chunk, "=")
+					part, err := base64.StdEncoding.DecodeString(chunk + strings.Repeat("=", (4-len(chunk)%4)%4))
+					if err != nil {
+						t.Fatalf("invalid base64 body %q: %v", data, err)
+					}
+					decoded = append(decoded, part...)
+				}
+				data = decoded
+			}
+
+			messages, trailers := readFrames(t, data)
+			if len(messages) != 1 {
+				t.Fatalf("got %d messages, want 1", len(messages))
+			}
+			var response healthpb.HealthCheckResponse
+			if err := proto.Unmarshal(messages[0], &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Status != healthpb.HealthCheckResponse_SERVING {
+				t.Errorf("status = %s, want SERVING", response.Status)
+			}
+			if trailers != "grpc-status: 0\r\n" {
+				t.Errorf("trailers = %q, want grpc-status 0", trailers)
+			}
+		})
+	}
+}
+
+func TestTrailersOnly(t *testing.T) {
+	server := newTestServer(t, Config{})
+
+	resp, data := post(t, server.URL+"/grpc.health.v1.Health/Check", "application/grpc-web+proto",
+		frame(t, &healthpb.HealthCheckRequest{Service: "missing"}), http.Header{})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Grpc-Status"); got != "5" {
+		t.Errorf("Grpc-Status = %q, want 5", got)
+	}
+	if got := resp.Header.Get("Grpc-Message"); got != "unknown service" {
+		t.Errorf("Grpc-Message = %q, want unknown service", got)
+	}
+	if len(data) != 0 {
+		t.Errorf("body = %x, want none", data)
+	}
+}
+
+func TestServerStreaming(t *testing.T) {
+	server := newTestServer(t, Config{})
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/grpc.health.v1.Health/Watch",
+		bytes.NewReader(frame(t, &healthpb.HealthCheckRequest{Service: "echo"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// Watch never ends, so the first message must be flushed on its own.
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(resp.Body, header); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, binary.BigEndian.Uint32(header[1:]))
+	if _, err := io.ReadFull(resp.Body, data); err != nil {
+		t.Fatal(err)
+	}
+
+	var response healthpb.HealthCheckResponse
+	if err := proto.Unmarshal(data, &response); err != nil {
+		t.Fatal(err)
+	}
+	if header[0] != 0 || response.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("first frame = %x %s, want a SERVING message", header[0], response.Status)
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	server := newTestServer(t, Config{
+		AllowedOrigins: []string{"http://allowed.test"},
+		AllowedHeaders: []string{"x-echo-fault-abort-percent"},
+	})
+
+	tests := []struct {
+		name      string
+		origin    string
+		headers   string
+		wantAllow bool
+	}{
+		{name: "allowed", origin: "http://allowed.test", headers: "x-grpc-web, content-type, X-Echo-Fault-Abort-Percent", wantAllow: true},
+		{name: "no headers", origin: "http://allowed.test", wantAllow: true},
+		{name: "origin not allowed", origin: "http://other.test", headers: "content-type"},
+		{name: "header not allowed", origin: "http://allowed.test", headers: "content-type, x-other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodOptions, server.URL+"/grpc.health.v1.Health/Check", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			allowOrigin := resp.Header.Get("Access-Control-Allow-Origin")
+			if tt.wantAllow != (allowOrigin == tt.origin) {
+				t.Errorf("Access-Control-Allow-Origin = %q, want allowed %v", allowOrigin, tt.wantAllow)
+			}
+			if tt.wantAllow && resp.Header.Get("Access-Control-Allow-Methods") != http.MethodPost {
+				t.Errorf("Access-Control-Allow-Methods = %q, want POST", resp.Header.Get("Access-Control-Allow-Methods"))
+			}
+		})
+	}
+}
+
+func TestAnyOriginAndHeader(t *testing.T) {
+	server := newTestServer(t, Config{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}})
+
+	req, err := http.NewRequest(http.MethodOptions, server.URL+"/grpc.health.v1.Health/Check", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Origin", "http://any.test")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "x-anything")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "http://any.test" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the origin", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Headers"); got != "X-Anything" {
+		t.Errorf("Access-Control-Allow-Headers = %q, want X-Anything", got)
+	}
+}
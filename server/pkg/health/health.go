@@ -0,0 +1,195 @@
+package health
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// ErrShutdown is returned for status changes made after Shutdown.
+var ErrShutdown = errors.New("health registry is shut down")
+
+// Registry keeps the serving status of every service exposed by the server
+// and fans status changes out to Watch subscribers.
+type Registry struct {
+	mu          sync.RWMutex
+	statuses    map[string]healthpb.HealthCheckResponse_ServingStatus
+	subscribers map[string]map[chan healthpb.HealthCheckResponse_ServingStatus]struct{}
+	restores    map[string]*time.Timer
+	shutdown    bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		statuses:    make(map[string]healthpb.HealthCheckResponse_ServingStatus),
+		subscribers: make(map[string]map[chan healthpb.HealthCheckResponse_ServingStatus]struct{}),
+		restores:    make(map[string]*time.Timer),
+	}
+}
+
+// SetServingStatus records the status of service, registering it when it is
+// not known yet, and notifies its subscribers. Any pending restore scheduled
+// by SetServingStatusFor is cancelled. It returns ErrShutdown once the
+// registry is shut down.
+func (r *Registry) SetServingStatus(service string, status healthpb.HealthCheckResponse_ServingStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shutdown {
+		return ErrShutdown
+	}
+
+	r.cancelRestore(service)
+	r.set(service, status)
+	return nil
+}
+
+// Shutdown sets every service to NOT_SERVING and rejects any later status
+// change, so a draining server never reports itself healthy again.
+func (r *Registry) Shutdown() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for service := range r.restores {
+		r.cancelRestore(service)
+	}
+	for service := range r.statuses {
+		r.set(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	r.shutdown = true
+}
+
+// SetServingStatusFor behaves like SetServingStatus but puts the previous
+// status back once duration has elapsed. A service that was not registered
+// before is removed again. It returns the status that will be restored.
+func (r *Registry) SetServingStatusFor(service string, status healthpb.HealthCheckResponse_ServingStatus, duration time.Duration) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shutdown {
+		return healthpb.HealthCheckResponse_UNKNOWN, ErrShutdown
+	}
+
+	r.cancelRestore(service)
+
+	previous, registered := r.statuses[service]
+	if !registered {
+		previous = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+
+	r.set(service, status)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(duration, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.restores[service] != timer {
+			return
+		}
+		delete(r.restores, service)
+
+		if !registered {
+			r.remove(service)
+			return
+		}
+		r.set(service, previous)
+	})
+	r.restores[service] = timer
+
+	return previous, nil
+}
+
+// Status returns the status of service and whether it is registered.
+func (r *Registry) Status(service string) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	status, ok := r.statuses[service]
+	return status, ok
+}
+
+// Services returns a snapshot of every registered service and its status.
+func (r *Registry) Services() map[string]healthpb.HealthCheckResponse_ServingStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	services := make(map[string]healthpb.HealthCheckResponse_ServingStatus, len(r.statuses))
+	for service, status := range r.statuses {
+		services[service] = status
+	}
+	return services
+}
+
+// Subscribe returns a channel that immediately yields the current status of
+// service (SERVICE_UNKNOWN when it is not registered) and every change after
+// that. Slow readers only ever see the latest status. The returned function
+// must be called to release the subscription.
+func (r *Registry) Subscribe(service string) (<-chan healthpb.HealthCheckResponse_ServingStatus, func()) {
+	ch := make(chan healthpb.HealthCheckResponse_ServingStatus, 1)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subscribers[service]; !ok {
+		r.subscribers[service] = make(map[chan healthpb.HealthCheckResponse_ServingStatus]struct{})
+	}
+	r.subscribers[service][ch] = struct{}{}
+
+	status, ok := r.statuses[service]
+	if !ok {
+		status = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+	notify(ch, status)
+
+	return ch, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		delete(r.subscribers[service], ch)
+		if len(r.subscribers[service]) == 0 {
+			delete(r.subscribers, service)
+		}
+	}
+}
+
+func (r *Registry) set(service string, status healthpb.HealthCheckResponse_ServingStatus) {
+	if r.shutdown {
+		return
+	}
+
+	r.statuses[service] = status
+	for ch := range r.subscribers[service] {
+		notify(ch, status)
+	}
+}
+
+func (r *Registry) remove(service string) {
+	if r.shutdown {
+		return
+	}
+
+	delete(r.statuses, service)
+	for ch := range r.subscribers[service] {
+		notify(ch, healthpb.HealthCheckResponse_SERVICE_UNKNOWN)
+	}
+}
+
+func (r *Registry) cancelRestore(service string) {
+	if timer, ok := r.restores[service]; ok {
+		timer.Stop()
+		delete(r.restores, service)
+	}
+}
+
+// notify replaces any undelivered status in ch with status so subscribers
+// never block the registry.
+func notify(ch chan healthpb.HealthCheckResponse_ServingStatus, status healthpb.HealthCheckResponse_ServingStatus) {
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- status
+}
@@ -0,0 +1,190 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// receive waits for the next status delivered on ch.
+func receive(t *testing.T, ch <-chan healthpb.HealthCheckResponse_ServingStatus) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+
+	select {
+	case status := <-ch:
+		return status
+	case <-time.After(5 * time.Second):
+		t.Fatal("no status received")
+		return healthpb.HealthCheckResponse_UNKNOWN
+	}
+}
+
+func TestSetServingStatus(t *testing.T) {
+	r := NewRegistry()
+
+	if _, ok := r.Status("echo"); ok {
+		t.Fatal("Status() reports an unregistered service")
+	}
+
+	if err := r.SetServingStatus("echo", healthpb.HealthCheckResponse_SERVING); err != nil {
+		t.Fatalf("SetServingStatus() failed: %v", err)
+	}
+	if err := r.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING); err != nil {
+		t.Fatalf("SetServingStatus() failed: %v", err)
+	}
+
+	if status, ok := r.Status("echo"); !ok || status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Status(echo) = %s, %v, want SERVING, true", status, ok)
+	}
+
+	services := r.Services()
+	if len(services) != 2 || services[""] != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Services() = %v, want echo and the server", services)
+	}
+
+	// The snapshot is a copy.
+	services["echo"] = healthpb.HealthCheckResponse_NOT_SERVING
+	if status, _ := r.Status("echo"); status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Status(echo) = %s after changing the snapshot, want SERVING", status)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	r := NewRegistry()
+
+	ch, unsubscribe := r.Subscribe("echo")
+	if got := receive(t, ch); got != healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+		t.Errorf("initial status = %s, want SERVICE_UNKNOWN", got)
+	}
+
+	r.SetServingStatus("echo", healthpb.HealthCheckResponse_SERVING)
+	if got := receive(t, ch); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("status = %s, want SERVING", got)
+	}
+
+	// A slow reader only sees the latest status.
+	r.SetServingStatus("echo", healthpb.HealthCheckResponse_NOT_SERVING)
+	r.SetServingStatus("echo", healthpb.HealthCheckResponse_SERVING)
+	if got := receive(t, ch); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("status = %s, want SERVING", got)
+	}
+	select {
+	case status := <-ch:
+		t.Errorf("received stale status %s", status)
+	default:
+	}
+
+	// Other services do not notify.
+	r.SetServingStatus("other", healthpb.HealthCheckResponse_NOT_SERVING)
+	select {
+	case status := <-ch:
+		t.Errorf("received %s for another service", status)
+	default:
+	}
+
+	unsubscribe()
+	r.SetServingStatus("echo", healthpb.HealthCheckResponse_NOT_SERVING)
+	select {
+	case status := <-ch:
+		t.Errorf("received %s after unsubscribing", status)
+	default:
+	}
+
+	ch, unsubscribe = r.Subscribe("echo")
+	defer unsubscribe()
+	if got := receive(t, ch); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("initial status = %s, want NOT_SERVING", got)
+	}
+}
+
+func TestSetServingStatusFor(t *testing.T) {
+	r := NewRegistry()
+	r.SetServingStatus("echo", healthpb.HealthCheckResponse_SERVING)
+
+	ch, unsubscribe := r.Subscribe("echo")
+	defer unsubscribe()
+	receive(t, ch)
+
+	previous, err := r.SetServingStatusFor("echo", healthpb.HealthCheckResponse_NOT_SERVING, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SetServingStatusFor() failed: %v", err)
+	}
+	if previous != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("SetServingStatusFor() = %s, want SERVING", previous)
+	}
+	if got := receive(t, ch); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("status = %s, want NOT_SERVING", got)
+	}
+	if got := receive(t, ch); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("restored status = %s, want SERVING", got)
+	}
+}
+
+func TestSetServingStatusForUnregistered(t *testing.T) {
+	r := NewRegistry()
+
+	ch, unsubscribe := r.Subscribe("echo")
+	defer unsubscribe()
+	receive(t, ch)
+
+	previous, err := r.SetServingStatusFor("echo", healthpb.HealthCheckResponse_SERVING, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SetServingStatusFor() failed: %v", err)
+	}
+	if previous != healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+		t.Errorf("SetServingStatusFor() = %s, want SERVICE_UNKNOWN", previous)
+	}
+	receive(t, ch)
+
+	if got := receive(t, ch); got != healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+		t.Errorf("restored status = %s, want SERVICE_UNKNOWN", got)
+	}
+	if _, ok := r.Status("echo"); ok {
+		t.Error("service is still registered after the restore")
+	}
+}
+
+func TestSetServingStatusCancelsRestore(t *testing.T) {
+	r := NewRegistry()
+	r.SetServingStatus("echo", healthpb.HealthCheckResponse_SERVING)
+
+	r.SetServingStatusFor("echo", healthpb.HealthCheckResponse_NOT_SERVING, 10*time.Millisecond)
+	r.SetServingStatus("echo", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	time.Sleep(50 * time.Millisecond)
+	if status, _ := r.Status("echo"); status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Status(echo) = %s, want NOT_SERVING", status)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	r := NewRegistry()
+	r.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	r.SetServingStatus("echo", healthpb.HealthCheckResponse_SERVING)
+	r.SetServingStatusFor("timed", healthpb.HealthCheckResponse_SERVING, 10*time.Millisecond)
+
+	ch, unsubscribe := r.Subscribe("echo")
+	defer unsubscribe()
+	receive(t, ch)
+
+	r.Shutdown()
+	if got := receive(t, ch); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("status = %s, want NOT_SERVING", got)
+	}
+
+	if err := r.SetServingStatus("echo", healthpb.HealthCheckResponse_SERVING); err != ErrShutdown {
+		t.Errorf("SetServingStatus() = %v, want ErrShutdown", err)
+	}
+	if _, err := r.SetServingStatusFor("echo", healthpb.HealthCheckResponse_SERVING, time.Second); err != ErrShutdown {
+		t.Errorf("SetServingStatusFor() = %v, want ErrShutdown", err)
+	}
+
+	// The pending restore of "timed" must not bring it back.
+	time.Sleep(50 * time.Millisecond)
+	for service, status := range r.Services() {
+		if status != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("Status(%q) = %s after Shutdown, want NOT_SERVING", service, status)
+		}
+	}
+}
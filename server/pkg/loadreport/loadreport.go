@@ -0,0 +1,73 @@
+// Package loadreport attaches ORCA load reports to every response so that
+// clients using weighted_round_robin can weigh this server against others.
+package loadreport
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/orca"
+)
+
+// Reporter reports a fixed CPU utilization, to simulate a busier or idler
+// server, along with the measured rate of calls.
+type Reporter struct {
+	recorder orca.ServerMetricsRecorder
+	calls    atomic.Int64
+}
+
+func NewReporter(cpuUtilization float64) *Reporter {
+	recorder := orca.NewServerMetricsRecorder()
+	recorder.SetCPUUtilization(cpuUtilization)
+
+	return &Reporter{
+		recorder: recorder,
+	}
+}
+
+// ServerOption makes the server send the report in the trailers of every
+// call.
+func (r *Reporter) ServerOption() grpc.ServerOption {
+	return orca.CallMetricsServerOption(r.recorder)
+}
+
+// Run updates the reported calls per second every interval until ctx is
+// done.
+func (r *Reporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			r.recorder.SetQPS(float64(r.calls.Swap(0)) / now.Sub(last).Seconds())
+			last = now
+		}
+	}
+}
+
+// UnaryServerInterceptor counts calls and, by fetching the call's recorder,
+// asks the ORCA interceptor to send the report; it must run inside the
+// interceptors of ServerOption.
+func (r *Reporter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		r.calls.Add(1)
+		orca.CallMetricsRecorderFromContext(ctx)
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor.
+func (r *Reporter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		r.calls.Add(1)
+		orca.CallMetricsRecorderFromContext(ss.Context())
+		return handler(srv, ss)
+	}
+}
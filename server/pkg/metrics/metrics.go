@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	handled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "echo_grpc_server_handled_total",
+		Help: "Total number of RPCs completed on the server.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"})
+
+	handlingSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "echo_grpc_server_handling_seconds",
+		Help:    "Time taken by the server to complete RPCs.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"})
+
+	activeStreams = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "echo_grpc_server_active_streams",
+		Help: "Number of streaming RPCs currently in progress.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+
+	messagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "echo_grpc_server_msg_received_total",
+		Help: "Total number of stream messages received by the server.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+
+	messagesSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "echo_grpc_server_msg_sent_total",
+		Help: "Total number of stream messages sent by the server.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+
+	streamMessages = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "echo_grpc_server_stream_messages",
+		Help:    "Number of messages exchanged per completed stream.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"grpc_type", "grpc_service", "grpc_method", "direction"})
+
+	connections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "echo_grpc_server_connections",
+		Help: "Number of open client connections.",
+	})
+
+	connectionsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "echo_grpc_server_connections_total",
+		Help: "Total number of client connections accepted.",
+	})
+
+	goAways = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "echo_grpc_server_goaways_sent_total",
+		Help: "Total number of HTTP/2 GOAWAY frames sent by the server.",
+	}, []string{"code", "reason"})
+)
+
+// ConnectionOpened counts a new client connection, call the returned function
+// once it is closed.
+func ConnectionOpened() func() {
+	connectionsTotal.Inc()
+	connections.Inc()
+
+	return connections.Dec
+}
+
+// GoAwaySent counts a GOAWAY frame by HTTP/2 error code and reason, e.g.
+// NO_ERROR/max_age or ENHANCE_YOUR_CALM/too_many_pings.
+func GoAwaySent(code, reason string) {
+	goAways.WithLabelValues(code, reason).Inc()
+}
+
+// Serve exposes the default registry on /metrics at addr.
+func Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	return http.ListenAndServe(addr, mux)
+}
+
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		service, method := splitMethod(info.FullMethod)
+		observe("unary", service, method, err, start)
+
+		return resp, err
+	}
+}
+
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		grpcType := streamType(info)
+		service, method := splitMethod(info.FullMethod)
+
+		activeStreams.WithLabelValues(grpcType, service, method).Inc()
+		defer activeStreams.WithLabelValues(grpcType, service, method).Dec()
+
+		stream := &serverStream{
+			ServerStream: ss,
+			received:     messagesReceived.WithLabelValues(grpcType, service, method),
+			sent:         messagesSent.WithLabelValues(grpcType, service, method),
+		}
+		err := handler(srv, stream)
+
+		observe(grpcType, service, method, err, start)
+		streamMessages.WithLabelValues(grpcType, service, method, "received").Observe(float64(stream.receivedCount))
+		streamMessages.WithLabelValues(grpcType, service, method, "sent").Observe(float64(stream.sentCount))
+
+		return err
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	received      prometheus.Counter
+	sent          prometheus.Counter
+	receivedCount int
+	sentCount     int
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Inc()
+		s.sentCount++
+	}
+	return err
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received.Inc()
+		s.receivedCount++
+	}
+	return err
+}
+
+func observe(grpcType, service, method string, err error, start time.Time) {
+	code := status.Code(err).String()
+	handled.WithLabelValues(grpcType, service, method, code).Inc()
+	handlingSeconds.WithLabelValues(grpcType, service, method, code).Observe(time.Since(start).Seconds())
+}
+
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return "bidi_stream"
+	case info.IsClientStream:
+		return "client_stream"
+	default:
+		return "server_stream"
+	}
+}
+
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
@@ -8,9 +8,67 @@ import (
 
 type Settings struct {
 	Port                 string        `envconfig:"GRPC_SERVER_PORT" default:"8081"`
+	MetricsPort          string        `envconfig:"METRICS_PORT" default:"9090"`
 	GRPCKeepalive        bool          `envconfig:"GRPC_SERVER_KEEPALIVE" default:"false"`
 	GRPCKeepaliveTime    time.Duration `envconfig:"GRPC_SERVER_KEEPALIVE_TIME" default:"2h"`
 	GRPCKeepaliveTimeout time.Duration `envconfig:"GRPC_SERVER_KEEPALIVE_TIMEOUT" default:"20s"`
+
+	// Zero keeps grpc's default of never closing connections.
+	GRPCMaxConnectionIdle     time.Duration `envconfig:"GRPC_SERVER_MAX_CONNECTION_IDLE" default:"0s"`
+	GRPCMaxConnectionAge      time.Duration `envconfig:"GRPC_SERVER_MAX_CONNECTION_AGE" default:"0s"`
+	GRPCMaxConnectionAgeGrace time.Duration `envconfig:"GRPC_SERVER_MAX_CONNECTION_AGE_GRACE" default:"0s"`
+
+	GRPCKeepaliveMinTime             time.Duration `envconfig:"GRPC_SERVER_KEEPALIVE_MIN_TIME" default:"5m"`
+	GRPCKeepalivePermitWithoutStream bool          `envconfig:"GRPC_SERVER_KEEPALIVE_PERMIT_WITHOUT_STREAM" default:"false"`
+
+	GRPCAdmin bool `envconfig:"GRPC_SERVER_ADMIN" default:"false"`
+
+	// LoadReportCPUUtilization above zero attaches ORCA load reports with
+	// this utilization to every response, for weighted_round_robin clients.
+	LoadReportCPUUtilization float64 `envconfig:"LOAD_REPORT_CPU_UTILIZATION" default:"0"`
+
+	// GRPCWeb serves gRPC-Web for browsers on GRPCWebPort, next to native
+	// gRPC on Port.
+	GRPCWeb               bool     `envconfig:"GRPC_WEB" default:"false"`
+	GRPCWebPort           string   `envconfig:"GRPC_WEB_PORT" default:"8082"`
+	GRPCWebAllowedOrigins []string `envconfig:"GRPC_WEB_ALLOWED_ORIGINS" default:"*"`
+	GRPCWebAllowedHeaders []string `envconfig:"GRPC_WEB_ALLOWED_HEADERS" default:"*"`
+
+	ServerStreamCount    int           `envconfig:"SERVER_STREAM_COUNT" default:"5"`
+	ServerStreamInterval time.Duration `envconfig:"SERVER_STREAM_INTERVAL" default:"1s"`
+	ServerStreamJitter   time.Duration `envconfig:"SERVER_STREAM_JITTER" default:"0s"`
+
+	ShutdownDrainPeriod time.Duration `envconfig:"SHUTDOWN_DRAIN_PERIOD" default:"5s"`
+	ShutdownTimeout     time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"30s"`
+
+	GRPCTLS               bool          `envconfig:"GRPC_SERVER_TLS" default:"false"`
+	GRPCTLSCertFile       string        `envconfig:"GRPC_SERVER_TLS_CERT_FILE"`
+	GRPCTLSKeyFile        string        `envconfig:"GRPC_SERVER_TLS_KEY_FILE"`
+	GRPCTLSClientCAFile   string        `envconfig:"GRPC_SERVER_TLS_CLIENT_CA_FILE"`
+	GRPCTLSClientAuth     string        `envconfig:"GRPC_SERVER_TLS_CLIENT_AUTH"`
+	GRPCTLSReloadInterval time.Duration `envconfig:"GRPC_SERVER_TLS_RELOAD_INTERVAL" default:"30s"`
+
+	PodName      string `envconfig:"POD_NAME"`
+	PodNamespace string `envconfig:"POD_NAMESPACE"`
+	NodeName     string `envconfig:"NODE_NAME"`
+
+	FaultAbortPercent      float64       `envconfig:"FAULT_ABORT_PERCENT" default:"0"`
+	FaultAbortCode         string        `envconfig:"FAULT_ABORT_CODE" default:"UNAVAILABLE"`
+	FaultAbortMessage      string        `envconfig:"FAULT_ABORT_MESSAGE" default:"fault injected"`
+	FaultDelayPercent      float64       `envconfig:"FAULT_DELAY_PERCENT" default:"0"`
+	FaultDelayDistribution string        `envconfig:"FAULT_DELAY_DISTRIBUTION" default:"fixed"`
+	FaultDelay             time.Duration `envconfig:"FAULT_DELAY" default:"0s"`
+	FaultDelayMin          time.Duration `envconfig:"FAULT_DELAY_MIN" default:"0s"`
+	FaultDelayMax          time.Duration `envconfig:"FAULT_DELAY_MAX" default:"0s"`
+	FaultDelayStddev       time.Duration `envconfig:"FAULT_DELAY_STDDEV" default:"0s"`
+	FaultDropPercent       float64       `envconfig:"FAULT_DROP_PERCENT" default:"0"`
+
+	TracingExporter     string  `envconfig:"TRACING_EXPORTER" default:"none"`
+	TracingServiceName  string  `envconfig:"TRACING_SERVICE_NAME" default:"echo-grpc-server"`
+	TracingOTLPEndpoint string  `envconfig:"TRACING_OTLP_ENDPOINT" default:"localhost:4317"`
+	TracingOTLPInsecure bool    `envconfig:"TRACING_OTLP_INSECURE" default:"true"`
+	TracingFilePath     string  `envconfig:"TRACING_FILE_PATH" default:"traces.json"`
+	TracingSampleRatio  float64 `envconfig:"TRACING_SAMPLE_RATIO" default:"1"`
 }
 
 func NewSettings() (Settings, error) {
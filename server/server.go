@@ -2,31 +2,135 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"strconv"
+	"time"
 
+	"github.com/rs/zerolog/log"
+	"github.com/zufardhiyaulhaq/echo-grpc/pkg/compression"
 	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/fault"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 type Server struct {
 	pb.UnimplementedServerServer
-	pb.UnimplementedHealthServer
+	injector   *fault.Injector
+	serverInfo *pb.ServerInfo
 }
 
 func (s *Server) GetReply(ctx context.Context, msg *pb.Message) (*pb.Response, error) {
-	return &pb.Response{
-		Success:  true,
-		Response: "from server:" + msg.Message,
-	}, nil
+	if err := s.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+
+	grpc.SetHeader(ctx, metadata.Pairs("x-echo-hostname", s.serverInfo.Hostname))
+	requestEncoding, responseEncoding := compression.Encodings(ctx)
+
+	response := &pb.Response{
+		Success:             true,
+		Response:            "from server:" + msg.Message,
+		Payload:             msg.Payload,
+		RequestEncoding:     requestEncoding,
+		ResponseEncoding:    responseEncoding,
+		PreviousRpcAttempts: previousAttempts(ctx),
+	}
+	if certificate := clientCertificate(ctx); certificate != nil {
+		response.ClientCertificateSubject = certificate.Subject.String()
+	}
+
+	return response, nil
 }
 
-func (s *Server) Check(ctx context.Context, req *pb.HealthCheckRequest) (*pb.HealthCheckResponse, error) {
-	return &pb.HealthCheckResponse{
-		Status: pb.HealthCheckResponse_SERVING,
-	}, nil
+// clientCertificate returns the verified client certificate of the call, nil
+// without mTLS.
+func clientCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return tlsInfo.State.VerifiedChains[0][0]
 }
-func (s *Server) Watch(req *pb.HealthCheckRequest, watch pb.Health_WatchServer) error {
-	return nil
+
+// previousAttempts returns the grpc-previous-rpc-attempts header a retried or
+// hedged call carries, 0 for a first attempt.
+func previousAttempts(ctx context.Context) int32 {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("grpc-previous-rpc-attempts")
+	if len(values) == 0 {
+		return 0
+	}
+
+	attempts, _ := strconv.ParseInt(values[0], 10, 32)
+	log.Info().
+		Int64("previous_rpc_attempts", attempts).
+		Msg("received retried call")
+	return int32(attempts)
+}
+
+func (s *Server) Inspect(ctx context.Context, msg *pb.Message) (*pb.InspectResponse, error) {
+	if err := s.injector.Inject(ctx); err != nil {
+		return nil, err
+	}
+
+	response := &pb.InspectResponse{
+		Success:  true,
+		Response: "from server:" + msg.Message,
+		Metadata: make(map[string]*pb.MetadataValues),
+		Server:   s.serverInfo,
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			response.Metadata[key] = &pb.MetadataValues{Values: values}
+		}
+		if authority := md.Get(":authority"); len(authority) > 0 {
+			response.Authority = authority[0]
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			response.PeerAddress = p.Addr.String()
+		}
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			response.Tls = &pb.TLSInfo{
+				Version:            tls.VersionName(tlsInfo.State.Version),
+				CipherSuite:        tls.CipherSuiteName(tlsInfo.State.CipherSuite),
+				ServerName:         tlsInfo.State.ServerName,
+				NegotiatedProtocol: tlsInfo.State.NegotiatedProtocol,
+			}
+
+			if certificate := clientCertificate(ctx); certificate != nil {
+				response.Tls.ClientCertificateVerified = true
+				response.Tls.ClientCertificateSubject = certificate.Subject.String()
+				response.Tls.ClientCertificateDnsNames = certificate.DNSNames
+				for _, uri := range certificate.URIs {
+					response.Tls.ClientCertificateUris = append(response.Tls.ClientCertificateUris, uri.String())
+				}
+			}
+		}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		response.DeadlineRemaining = durationpb.New(time.Until(deadline))
+	}
+
+	return response, nil
 }
 
-func NewServer() *Server {
-	return &Server{}
+func NewServer(injector *fault.Injector, serverInfo *pb.ServerInfo) *Server {
+	return &Server{
+		injector:   injector,
+		serverInfo: serverInfo,
+	}
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"github.com/zufardhiyaulhaq/echo-grpc/server/pkg/fault"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestGetReplyClientCertificateSubject(t *testing.T) {
+	injector, err := fault.NewInjector(fault.Config{DelayDistribution: fault.DistributionFixed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(injector, &pb.ServerInfo{})
+
+	certificate := &x509.Certificate{Subject: pkix.Name{CommonName: "client", Organization: []string{"echo"}}}
+
+	tests := []struct {
+		name     string
+		authInfo credentials.AuthInfo
+		want     string
+	}{
+		{name: "plaintext"},
+		{name: "tls without client certificate", authInfo: credentials.TLSInfo{}},
+		{
+			name: "verified client certificate",
+			authInfo: credentials.TLSInfo{State: tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{certificate}},
+			}},
+			want: "CN=client,O=echo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: tt.authInfo})
+
+			response, err := s.GetReply(ctx, &pb.Message{Message: "hello"})
+			if err != nil {
+				t.Fatalf("GetReply() failed: %v", err)
+			}
+			if response.ClientCertificateSubject != tt.want {
+				t.Errorf("client_certificate_subject = %q, want %q", response.ClientCertificateSubject, tt.want)
+			}
+		})
+	}
+}
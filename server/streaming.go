@@ -3,24 +3,66 @@ package main
 import (
 	"fmt"
 	"io"
+	"math/rand"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/zufardhiyaulhaq/echo-grpc/pkg/compression"
 	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// MaxServerStreamInterval bounds the interval between ServerStream
+// responses.
+const MaxServerStreamInterval = 24 * time.Hour
+
+// MinUntilCancelledInterval is the shortest interval of a stream that runs
+// until cancelled, so such a stream can not send in a busy loop.
+const MinUntilCancelledInterval = 10 * time.Millisecond
+
+// ServerStreamConfig holds the ServerStream defaults used when a request
+// leaves count, interval or jitter unset.
+type ServerStreamConfig struct {
+	Count          int
+	Interval       time.Duration
+	Jitter         time.Duration
+	UntilCancelled bool
+}
+
+// Validate checks that 0 <= jitter <= interval <= MaxServerStreamInterval,
+// and that the interval is at least MinUntilCancelledInterval when the stream
+// runs until cancelled.
+func (c ServerStreamConfig) Validate() error {
+	if c.Count < 0 {
+		return fmt.Errorf("count must not be negative")
+	}
+	if c.Interval < 0 || c.Interval > MaxServerStreamInterval {
+		return fmt.Errorf("interval must be between 0 and %s, got %s", MaxServerStreamInterval, c.Interval)
+	}
+	if c.UntilCancelled && c.Interval < MinUntilCancelledInterval {
+		return fmt.Errorf("interval must be at least %s with until_cancelled, got %s", MinUntilCancelledInterval, c.Interval)
+	}
+	if c.Jitter < 0 || c.Jitter > c.Interval {
+		return fmt.Errorf("jitter must be between 0 and the interval %s, got %s", c.Interval, c.Jitter)
+	}
+	return nil
+}
+
 type StreamingServer struct {
 	pb.UnimplementedStreamingServerServer
+	defaults ServerStreamConfig
 }
 
-func NewStreamingServer() *StreamingServer {
-	return &StreamingServer{}
+func NewStreamingServer(defaults ServerStreamConfig) *StreamingServer {
+	return &StreamingServer{
+		defaults: defaults,
+	}
 }
 
 func (s *StreamingServer) BidirectionalStream(stream pb.StreamingServer_BidirectionalStreamServer) error {
 	var serverSeq int64 = 0
+	attempts := previousAttempts(stream.Context())
 
 	for {
 		msg, err := stream.Recv()
@@ -42,7 +84,10 @@ func (s *StreamingServer) BidirectionalStream(stream pb.StreamingServer_Bidirect
 			Timestamp:      time.Now().UnixNano(),
 			Response:       "from server: " + msg.Message,
 			Success:        true,
+			Payload:        msg.Payload,
 		}
+		response.RequestEncoding, response.ResponseEncoding = compression.Encodings(stream.Context())
+		response.PreviousRpcAttempts = attempts
 
 		if err := stream.Send(response); err != nil {
 			return err
@@ -60,18 +105,51 @@ func (s *StreamingServer) ServerStream(msg *pb.StreamMessage, stream pb.Streamin
 		return status.Error(codes.InvalidArgument, "stream_id is required")
 	}
 
+	config, err := s.serverStreamConfig(msg)
+	if err != nil {
+		return err
+	}
+	attempts := previousAttempts(stream.Context())
+
+	total := fmt.Sprintf("%d", config.Count)
+	if config.UntilCancelled {
+		total = "∞"
+	}
+
 	log.Info().
 		Str("stream_id", msg.StreamId).
-		Msg("server stream: starting 5 echoes")
+		Str("count", total).
+		Dur("interval", config.Interval).
+		Dur("jitter", config.Jitter).
+		Msg("server stream: starting echoes")
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for i := 1; config.UntilCancelled || i <= config.Count; i++ {
+		if i > 1 {
+			timer.Reset(config.next())
+			select {
+			case <-stream.Context().Done():
+				log.Info().
+					Str("stream_id", msg.StreamId).
+					Int("echo", i-1).
+					Msg("server stream: cancelled by client")
+				return status.FromContextError(stream.Context().Err()).Err()
+			case <-timer.C:
+			}
+		}
 
-	for i := 1; i <= 5; i++ {
 		response := &pb.StreamResponse{
 			StreamId:       msg.StreamId,
 			SequenceNumber: int64(i),
 			Timestamp:      time.Now().UnixNano(),
-			Response:       "from server: " + msg.Message + " (echo " + fmt.Sprintf("%d/5", i) + ")",
+			Response:       "from server: " + msg.Message + " (echo " + fmt.Sprintf("%d/%s", i, total) + ")",
 			Success:        true,
+			Payload:        msg.Payload,
 		}
+		response.RequestEncoding, response.ResponseEncoding = compression.Encodings(stream.Context())
+		response.PreviousRpcAttempts = attempts
 
 		if err := stream.Send(response); err != nil {
 			return err
@@ -81,13 +159,53 @@ func (s *StreamingServer) ServerStream(msg *pb.StreamMessage, stream pb.Streamin
 			Str("stream_id", msg.StreamId).
 			Int("echo", i).
 			Msg("server stream: sent echo")
+	}
 
-		if i < 5 {
-			time.Sleep(1 * time.Second)
+	return nil
+}
+
+// serverStreamConfig merges the per-call fields of msg over the defaults.
+func (s *StreamingServer) serverStreamConfig(msg *pb.StreamMessage) (ServerStreamConfig, error) {
+	config := s.defaults
+
+	if msg.Count < 0 {
+		return config, status.Error(codes.InvalidArgument, "count must not be negative")
+	}
+	if msg.Count > 0 {
+		config.Count = int(msg.Count)
+	}
+
+	if msg.Interval != nil {
+		if err := msg.Interval.CheckValid(); err != nil {
+			return config, status.Errorf(codes.InvalidArgument, "invalid interval: %v", err)
 		}
+		config.Interval = msg.Interval.AsDuration()
 	}
 
-	return nil
+	if msg.Jitter != nil {
+		if err := msg.Jitter.CheckValid(); err != nil {
+			return config, status.Errorf(codes.InvalidArgument, "invalid jitter: %v", err)
+		}
+		config.Jitter = msg.Jitter.AsDuration()
+	}
+
+	config.UntilCancelled = msg.UntilCancelled
+
+	if err := config.Validate(); err != nil {
+		return config, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return config, nil
+}
+
+// next returns the interval shifted by a uniform random offset in
+// [-Jitter, +Jitter]. Validate keeps Jitter below the interval and
+// MaxServerStreamInterval, so the result is never negative and 2*Jitter
+// cannot overflow.
+func (c ServerStreamConfig) next() time.Duration {
+	if c.Jitter <= 0 {
+		return c.Interval
+	}
+	return c.Interval + time.Duration(rand.Int63n(int64(2*c.Jitter)+1)) - c.Jitter
 }
 
 func (s *StreamingServer) ClientStream(stream pb.StreamingServer_ClientStreamServer) error {
@@ -104,6 +222,8 @@ func (s *StreamingServer) ClientStream(stream pb.StreamingServer_ClientStreamSer
 				Response:       fmt.Sprintf("from server: received %d messages", count),
 				Success:        true,
 			}
+			response.RequestEncoding, response.ResponseEncoding = compression.Encodings(stream.Context())
+			response.PreviousRpcAttempts = previousAttempts(stream.Context())
 
 			log.Info().
 				Str("stream_id", streamId).
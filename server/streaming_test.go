@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/zufardhiyaulhaq/echo-grpc/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestServerStreamConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ServerStreamConfig
+		wantErr string
+	}{
+		{name: "defaults", config: ServerStreamConfig{Count: 5, Interval: time.Second}},
+		{name: "no interval", config: ServerStreamConfig{Count: 5}},
+		{name: "max interval", config: ServerStreamConfig{Interval: MaxServerStreamInterval, Jitter: MaxServerStreamInterval}},
+		{name: "negative count", config: ServerStreamConfig{Count: -1}, wantErr: "count"},
+		{name: "negative interval", config: ServerStreamConfig{Interval: -time.Second}, wantErr: "interval"},
+		{name: "interval above max", config: ServerStreamConfig{Interval: MaxServerStreamInterval + 1}, wantErr: "interval"},
+		{name: "negative jitter", config: ServerStreamConfig{Interval: time.Second, Jitter: -1}, wantErr: "jitter"},
+		{name: "jitter above interval", config: ServerStreamConfig{Interval: time.Second, Jitter: 2 * time.Second}, wantErr: "jitter"},
+		{name: "until cancelled", config: ServerStreamConfig{Interval: MinUntilCancelledInterval, UntilCancelled: true}},
+		{
+			name:    "until cancelled without interval",
+			config:  ServerStreamConfig{UntilCancelled: true},
+			wantErr: "until_cancelled",
+		},
+		{
+			name:    "until cancelled below min interval",
+			config:  ServerStreamConfig{Interval: time.Millisecond, UntilCancelled: true},
+			wantErr: "until_cancelled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServerStreamConfig(t *testing.T) {
+	s := NewStreamingServer(ServerStreamConfig{Count: 5, Interval: time.Second})
+
+	tests := []struct {
+		name     string
+		msg      *pb.StreamMessage
+		want     ServerStreamConfig
+		wantCode codes.Code
+	}{
+		{
+			name: "defaults",
+			msg:  &pb.StreamMessage{},
+			want: ServerStreamConfig{Count: 5, Interval: time.Second},
+		},
+		{
+			name: "overrides",
+			msg:  &pb.StreamMessage{Count: 2, Interval: durationpb.New(time.Minute), Jitter: durationpb.New(time.Second)},
+			want: ServerStreamConfig{Count: 2, Interval: time.Minute, Jitter: time.Second},
+		},
+		{
+			name: "until cancelled",
+			msg:  &pb.StreamMessage{UntilCancelled: true},
+			want: ServerStreamConfig{Count: 5, Interval: time.Second, UntilCancelled: true},
+		},
+		{
+			name:     "until cancelled without interval",
+			msg:      &pb.StreamMessage{UntilCancelled: true, Interval: durationpb.New(0)},
+			wantCode: codes.InvalidArgument,
+		},
+		{name: "negative count", msg: &pb.StreamMessage{Count: -1}, wantCode: codes.InvalidArgument},
+		{
+			name:     "jitter above interval",
+			msg:      &pb.StreamMessage{Jitter: durationpb.New(time.Hour)},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "invalid interval",
+			msg:      &pb.StreamMessage{Interval: &durationpb.Duration{Seconds: 1, Nanos: -1}},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.serverStreamConfig(tt.msg)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("serverStreamConfig() error = %v, want code %s", err, tt.wantCode)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("serverStreamConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerStreamConfigNext(t *testing.T) {
+	config := ServerStreamConfig{Interval: time.Second, Jitter: 100 * time.Millisecond}
+	for i := 0; i < 1000; i++ {
+		if got := config.next(); got < 900*time.Millisecond || got > 1100*time.Millisecond {
+			t.Fatalf("next() = %s, want between 900ms and 1.1s", got)
+		}
+	}
+}
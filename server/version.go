@@ -0,0 +1,8 @@
+package main
+
+// Build information, set through -ldflags by the Makefile.
+var (
+	version   = "dev"
+	gitCommit = ""
+	buildDate = ""
+)